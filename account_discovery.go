@@ -0,0 +1,235 @@
+package keycard
+
+import (
+	"errors"
+
+	"github.com/status-im/keycard-go/crypto"
+	"github.com/status-im/keycard-go/derivationpath"
+)
+
+const (
+	DefaultGapLimit = 20
+	// DefaultMaxAccounts is the number of accounts scanned at most if DiscoveryOptions.MaxAccounts is 0.
+	DefaultMaxAccounts = 100
+	// DefaultMaxAddresses is the number of addresses scanned at most on each chain if
+	// DiscoveryOptions.MaxAddresses is 0.
+	DefaultMaxAddresses = 10000
+
+	// maxDiscoveryIndex is the first hardened index, which can't be used for accounts and addresses.
+	maxDiscoveryIndex = 0x80000000
+)
+
+var (
+	ErrInvalidGapLimit       = errors.New("gap limit must be greater than 0")
+	ErrInvalidDiscoveryLimit = errors.New("discovery limits must not exceed 2^31")
+	ErrAddressLimitReached   = errors.New("address limit reached before finding the gap of unused addresses")
+)
+
+// UsageOracle tells if the address of a public key has any history on chain.
+// The public key is uncompressed, the oracle is responsible of computing the right
+// address format for the chain it checks.
+type UsageOracle interface {
+	IsUsed(path []uint32, pubKey []byte) (bool, error)
+}
+
+// PublicKeySource returns the public key at an absolute path.
+type PublicKeySource interface {
+	PublicKey(path []uint32) ([]byte, error)
+}
+
+// CardKeySource exports every public key from the card.
+type CardKeySource struct {
+	cs *CommandSet
+}
+
+// NewCardKeySource returns a CardKeySource exporting keys with cs.
+func NewCardKeySource(cs *CommandSet) *CardKeySource {
+	return &CardKeySource{cs: cs}
+}
+
+// PublicKey implements the PublicKeySource interface.
+func (s *CardKeySource) PublicKey(path []uint32) ([]byte, error) {
+	_, pubKey, err := s.cs.ExportKey(true, false, true, derivationpath.Encode(path))
+	return pubKey, err
+}
+
+type extendedKey struct {
+	pubKey    []byte
+	chainCode []byte
+}
+
+// ExtendedKeySource exports the extended public key of the parent of each path
+// from the card once, and derives the keys of the non-hardened children locally.
+type ExtendedKeySource struct {
+	cs      *CommandSet
+	parents map[string]*extendedKey
+}
+
+// NewExtendedKeySource returns an ExtendedKeySource exporting the parent keys with cs.
+func NewExtendedKeySource(cs *CommandSet) *ExtendedKeySource {
+	return &ExtendedKeySource{
+		cs:      cs,
+		parents: make(map[string]*extendedKey),
+	}
+}
+
+// PublicKey implements the PublicKeySource interface.
+func (s *ExtendedKeySource) PublicKey(path []uint32) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot derive the master key from a parent key")
+	}
+
+	parentPath := derivationpath.Encode(path[:len(path)-1])
+	parent, ok := s.parents[parentPath]
+	if !ok {
+		pubKey, chainCode, err := s.cs.ExportExtendedPublicKey(parentPath)
+		if err != nil {
+			return nil, err
+		}
+
+		parent = &extendedKey{pubKey, chainCode}
+		s.parents[parentPath] = parent
+	}
+
+	pubKey, _, err := crypto.DeriveChildPublicKey(parent.pubKey, parent.chainCode, path[len(path)-1])
+	return pubKey, err
+}
+
+// DiscoveryOptions configures account discovery.
+type DiscoveryOptions struct {
+	// Purpose is one of derivationpath.PurposeBIP44, PurposeBIP49 or PurposeBIP84.
+	Purpose uint32
+	// CoinType is the BIP44 coin type, like derivationpath.CoinTypeEthereum.
+	CoinType uint32
+	// GapLimit is the number of consecutive unused addresses after which a chain is considered
+	// exhausted. If 0, DefaultGapLimit is used.
+	GapLimit int
+	// ScanInternal enables the discovery of the internal (change) chain.
+	ScanInternal bool
+	// MaxAccounts limits the number of scanned accounts. If 0, DefaultMaxAccounts is used.
+	MaxAccounts uint32
+	// MaxAddresses limits the number of addresses scanned on each chain. If 0, DefaultMaxAddresses is used.
+	// Discovery fails with ErrAddressLimitReached if a chain still has used addresses at the limit.
+	MaxAddresses uint32
+}
+
+// DiscoveredAddress is a used address found during discovery.
+type DiscoveredAddress struct {
+	Path   []uint32
+	PubKey []byte
+}
+
+// DiscoveredAccount is an account with at least one used address on the external chain.
+type DiscoveredAccount struct {
+	Index uint32
+	// Path is the account path, like m/44'/60'/0'.
+	Path []uint32
+	// Used contains the used addresses of the external chain and, if scanned, of the internal chain.
+	Used []*DiscoveredAddress
+	// NextExternalIndex is the index following the last used external address.
+	NextExternalIndex uint32
+	// NextInternalIndex is the index following the last used internal address.
+	NextInternalIndex uint32
+}
+
+// DiscoverAccounts walks accounts and address indexes as described in BIP44, asking oracle
+// whether each address has been used. Each chain is scanned until GapLimit consecutive unused addresses
+// are found, and discovery stops at the first account without used addresses on the external chain,
+// or after MaxAccounts accounts.
+func DiscoverAccounts(source PublicKeySource, oracle UsageOracle, opts DiscoveryOptions) ([]*DiscoveredAccount, error) {
+	if opts.GapLimit < 0 {
+		return nil, ErrInvalidGapLimit
+	}
+
+	if opts.GapLimit == 0 {
+		opts.GapLimit = DefaultGapLimit
+	}
+
+	if opts.MaxAccounts == 0 {
+		opts.MaxAccounts = DefaultMaxAccounts
+	}
+
+	if opts.MaxAddresses == 0 {
+		opts.MaxAddresses = DefaultMaxAddresses
+	}
+
+	if opts.MaxAccounts > maxDiscoveryIndex || opts.MaxAddresses > maxDiscoveryIndex {
+		return nil, ErrInvalidDiscoveryLimit
+	}
+
+	if _, err := derivationpath.NewAccountTemplate(opts.Purpose, opts.CoinType); err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*DiscoveredAccount, 0)
+
+	for account := uint32(0); account < opts.MaxAccounts; account++ {
+		used, next, err := scanChain(source, oracle, opts, account, derivationpath.ChangeExternal)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(used) == 0 {
+			break
+		}
+
+		discovered := &DiscoveredAccount{
+			Index:             account,
+			Path:              []uint32{derivationpath.Harden(opts.Purpose), derivationpath.Harden(opts.CoinType), derivationpath.Harden(account)},
+			Used:              used,
+			NextExternalIndex: next,
+		}
+
+		if opts.ScanInternal {
+			used, next, err := scanChain(source, oracle, opts, account, derivationpath.ChangeInternal)
+			if err != nil {
+				return nil, err
+			}
+
+			discovered.Used = append(discovered.Used, used...)
+			discovered.NextInternalIndex = next
+		}
+
+		accounts = append(accounts, discovered)
+	}
+
+	return accounts, nil
+}
+
+func scanChain(source PublicKeySource, oracle UsageOracle, opts DiscoveryOptions, account, change uint32) ([]*DiscoveredAddress, uint32, error) {
+	used := make([]*DiscoveredAddress, 0)
+	next := uint32(0)
+	gap := 0
+
+	for index := uint32(0); gap < opts.GapLimit; index++ {
+		if index == opts.MaxAddresses {
+			return nil, 0, ErrAddressLimitReached
+		}
+
+		path, err := derivationpath.NewAccountPath(opts.Purpose, opts.CoinType, account, change, index)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		pubKey, err := source.PublicKey(path)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		isUsed, err := oracle.IsUsed(path, pubKey)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if !isUsed {
+			gap++
+			continue
+		}
+
+		used = append(used, &DiscoveredAddress{Path: path, PubKey: pubKey})
+		next = index + 1
+		gap = 0
+	}
+
+	return used, next, nil
+}
@@ -0,0 +1,157 @@
+package keycard
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/derivationpath"
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeKeySource struct {
+	requests int
+}
+
+func (s *fakeKeySource) PublicKey(path []uint32) ([]byte, error) {
+	s.requests++
+	h := sha256.Sum256([]byte(derivationpath.Encode(path)))
+	return h[:], nil
+}
+
+type fakeOracle struct {
+	used map[string]bool
+}
+
+func (o *fakeOracle) IsUsed(path []uint32, pubKey []byte) (bool, error) {
+	return o.used[derivationpath.Encode(path)], nil
+}
+
+func TestDiscoverAccounts(t *testing.T) {
+	oracle := &fakeOracle{used: map[string]bool{
+		"m/44'/60'/0'/0/0": true,
+		"m/44'/60'/0'/0/3": true,
+		"m/44'/60'/0'/1/0": true,
+		"m/44'/60'/1'/0/0": true,
+		// beyond the gap limit
+		"m/44'/60'/1'/0/9": true,
+		// after an unused account
+		"m/44'/60'/3'/0/0": true,
+	}}
+
+	source := &fakeKeySource{}
+	accounts, err := DiscoverAccounts(source, oracle, DiscoveryOptions{
+		Purpose:      derivationpath.PurposeBIP44,
+		CoinType:     derivationpath.CoinTypeEthereum,
+		GapLimit:     5,
+		ScanInternal: true,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 2)
+
+	assert.Equal(t, uint32(0), accounts[0].Index)
+	assert.Equal(t, "m/44'/60'/0'", derivationpath.Encode(accounts[0].Path))
+	assert.Len(t, accounts[0].Used, 3)
+	assert.Equal(t, "m/44'/60'/0'/0/3", derivationpath.Encode(accounts[0].Used[1].Path))
+	assert.Equal(t, uint32(4), accounts[0].NextExternalIndex)
+	assert.Equal(t, uint32(1), accounts[0].NextInternalIndex)
+
+	assert.Equal(t, uint32(1), accounts[1].Index)
+	assert.Len(t, accounts[1].Used, 1)
+	assert.Equal(t, uint32(1), accounts[1].NextExternalIndex)
+	assert.Equal(t, uint32(0), accounts[1].NextInternalIndex)
+
+	// account 0: 4+5 external, 1+5 internal. account 1: 1+5 external, 5 internal. account 2: 5 external
+	assert.Equal(t, 31, source.requests)
+}
+
+func TestDiscoverAccounts_Options(t *testing.T) {
+	oracle := &fakeOracle{used: map[string]bool{
+		"m/84'/0'/0'/0/0":  true,
+		"m/84'/0'/0'/0/19": true,
+		"m/84'/0'/1'/0/0":  true,
+	}}
+
+	// default gap limit
+	accounts, err := DiscoverAccounts(&fakeKeySource{}, oracle, DiscoveryOptions{
+		Purpose:     derivationpath.PurposeBIP84,
+		CoinType:    derivationpath.CoinTypeBitcoin,
+		MaxAccounts: 1,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.Equal(t, uint32(20), accounts[0].NextExternalIndex)
+
+	_, err = DiscoverAccounts(&fakeKeySource{}, oracle, DiscoveryOptions{
+		Purpose:  derivationpath.PurposeBIP84,
+		CoinType: derivationpath.CoinTypeEthereum,
+	})
+	assert.Equal(t, derivationpath.ErrUnsupportedCoinType, err)
+
+	_, err = DiscoverAccounts(&fakeKeySource{}, oracle, DiscoveryOptions{
+		Purpose:  derivationpath.PurposeBIP44,
+		GapLimit: -1,
+	})
+	assert.Equal(t, ErrInvalidGapLimit, err)
+}
+
+type usedOracle struct{}
+
+func (usedOracle) IsUsed(path []uint32, pubKey []byte) (bool, error) {
+	return true, nil
+}
+
+func TestDiscoverAccounts_Limits(t *testing.T) {
+	source := &fakeKeySource{}
+	_, err := DiscoverAccounts(source, usedOracle{}, DiscoveryOptions{
+		Purpose:      derivationpath.PurposeBIP44,
+		CoinType:     derivationpath.CoinTypeEthereum,
+		MaxAddresses: 50,
+	})
+	assert.Equal(t, ErrAddressLimitReached, err)
+	assert.Equal(t, 50, source.requests)
+
+	// the accounts are limited by default
+	oracle := &fakeOracle{used: make(map[string]bool)}
+	for i := 0; i <= DefaultMaxAccounts; i++ {
+		oracle.used[fmt.Sprintf("m/44'/60'/%d'/0/0", i)] = true
+	}
+
+	accounts, err := DiscoverAccounts(&fakeKeySource{}, oracle, DiscoveryOptions{
+		Purpose:  derivationpath.PurposeBIP44,
+		CoinType: derivationpath.CoinTypeEthereum,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, accounts, DefaultMaxAccounts)
+
+	_, err = DiscoverAccounts(&fakeKeySource{}, oracle, DiscoveryOptions{
+		Purpose:     derivationpath.PurposeBIP44,
+		CoinType:    derivationpath.CoinTypeEthereum,
+		MaxAccounts: 0x80000001,
+	})
+	assert.Equal(t, ErrInvalidDiscoveryLimit, err)
+}
+
+func TestExtendedKeySource(t *testing.T) {
+	// BIP32 test vector 1, m/0H
+	c := newScriptedChannel().respond(InsExportKey, hexutils.HexToBytes("A1 45 80 21 035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56 82 20 47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141"), apdu.SwOK)
+	source := NewExtendedKeySource(NewCommandSet(c))
+
+	pubKey, err := source.PublicKey([]uint32{0x80000000, 1})
+	assert.NoError(t, err)
+	assert.Len(t, pubKey, 65)
+	assert.Equal(t, "04501E454BF00751F24B1B489AA925215D66AF2234E3891C3B21A52BEDB3CD711C", hexutils.BytesToHex(pubKey[:33]))
+
+	_, err = source.PublicKey([]uint32{0x80000000, 2})
+	assert.NoError(t, err)
+
+	// the parent key is exported only once
+	require.Len(t, c.commands, 1)
+	assert.Equal(t, uint8(P2ExportKeyExtendedPublic), c.commands[0].P2)
+	path, err := derivationpath.EncodeFromBytes(c.commands[0].Data)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/0'", path)
+}
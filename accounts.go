@@ -0,0 +1,185 @@
+package keycard
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/status-im/keycard-go/types"
+)
+
+var ErrMetadataConflict = errors.New("metadata has been modified on the card since it was read")
+
+// DefaultAccountParent is the parent path of the accounts managed by an AccountList on version 2 metadata,
+// unless changed with SetAccountGroup.
+var DefaultAccountParent = []uint32{0x8000002c, 0x8000003c, 0x80000000, 0}
+
+// AccountList is a read-modify-write view of the account indexes and card name
+// stored in the card metadata. Changes are kept locally until Save is called.
+// On version 2 metadata, the indexes are the last level of the accounts with the
+// type and parent path of the account group.
+type AccountList struct {
+	cs          *CommandSet
+	metadata    *types.Metadata
+	raw         []byte
+	accountType types.AccountType
+	parent      []uint32
+}
+
+// LoadAccountList reads the metadata from the card and returns an AccountList based on it.
+func (cs *CommandSet) LoadAccountList() (*AccountList, error) {
+	al := &AccountList{
+		cs:          cs,
+		accountType: types.AccountTypeEthereum,
+		parent:      DefaultAccountParent,
+	}
+
+	if err := al.Reload(); err != nil {
+		return nil, err
+	}
+
+	return al, nil
+}
+
+// Reload discards the local changes and reads the metadata from the card again.
+func (al *AccountList) Reload() error {
+	raw, err := al.cs.GetData(P1StoreDataPublic)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := parseMetadata(raw)
+	if err != nil {
+		return err
+	}
+
+	al.metadata = metadata
+	al.raw = raw
+
+	return nil
+}
+
+// Name returns the card name.
+func (al *AccountList) Name() string {
+	return al.metadata.Name()
+}
+
+// Rename changes the card name.
+func (al *AccountList) Rename(name string) error {
+	return al.metadata.SetName(name)
+}
+
+// SetAccountGroup sets the type and parent path of the accounts managed on version 2 metadata.
+// It has no effect on version 1 metadata.
+func (al *AccountList) SetAccountGroup(accountType types.AccountType, parent []uint32) {
+	al.accountType = accountType
+	al.parent = parent
+}
+
+// Indexes returns the ordered list of account indexes.
+func (al *AccountList) Indexes() []uint32 {
+	if al.metadata.Version() != types.MetadataVersion2 {
+		return al.metadata.Paths()
+	}
+
+	indexes := make([]uint32, 0)
+	for _, a := range al.metadata.Accounts() {
+		if a.Type == al.accountType && isChildOf(a.Path, al.parent) {
+			indexes = append(indexes, a.Path[len(a.Path)-1])
+		}
+	}
+
+	return indexes
+}
+
+// Add adds the specified account indexes. Indexes already in the list are ignored.
+func (al *AccountList) Add(indexes ...uint32) error {
+	for _, index := range indexes {
+		if al.metadata.Version() != types.MetadataVersion2 {
+			al.metadata.AddPath(index)
+			continue
+		}
+
+		path := al.accountPath(index)
+		if al.metadata.Account(path) != nil {
+			continue
+		}
+
+		if err := al.metadata.AddAccount(&types.Account{Type: al.accountType, Path: path}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the specified account indexes.
+func (al *AccountList) Remove(indexes ...uint32) {
+	for _, index := range indexes {
+		if al.metadata.Version() != types.MetadataVersion2 {
+			al.metadata.RemovePath(index)
+			continue
+		}
+
+		path := al.accountPath(index)
+		if a := al.metadata.Account(path); a != nil && a.Type == al.accountType {
+			al.metadata.RemoveAccount(path)
+		}
+	}
+}
+
+func (al *AccountList) accountPath(index uint32) []uint32 {
+	path := make([]uint32, len(al.parent), len(al.parent)+1)
+	copy(path, al.parent)
+	return append(path, index)
+}
+
+// Metadata returns the metadata including the local changes.
+func (al *AccountList) Metadata() *types.Metadata {
+	return al.metadata
+}
+
+// Save writes the local changes to the card.
+// Before writing, the metadata is read again and ErrMetadataConflict is returned if
+// it has been changed by someone else since the last Reload. In that case the caller
+// should Reload, apply its changes again and retry.
+func (al *AccountList) Save() error {
+	current, err := al.cs.GetData(P1StoreDataPublic)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(current, al.raw) {
+		return ErrMetadataConflict
+	}
+
+	data := al.metadata.Serialize()
+	if err := al.cs.StoreData(P1StoreDataPublic, data); err != nil {
+		return err
+	}
+
+	al.raw = data
+
+	return nil
+}
+
+func isChildOf(path []uint32, parent []uint32) bool {
+	if len(path) != len(parent)+1 {
+		return false
+	}
+
+	for i := range parent {
+		if path[i] != parent[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseMetadata(data []byte) (*types.Metadata, error) {
+	if len(data) == 0 {
+		return types.EmptyMetadata(), nil
+	}
+
+	return types.ParseMetadata(data)
+}
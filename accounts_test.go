@@ -0,0 +1,111 @@
+package keycard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMetadataChannel returns a channel storing the public data in stored.
+func newMetadataChannel(stored *[]byte) *scriptedChannel {
+	return newScriptedChannel().
+		on(InsGetData, func(*apdu.Command) *apdu.Response {
+			return response(*stored, apdu.SwOK)
+		}).
+		on(InsStoreData, func(cmd *apdu.Command) *apdu.Response {
+			*stored = cmd.Data
+			return response(nil, apdu.SwOK)
+		})
+}
+
+func TestCommandSet_GetMetadataEmpty(t *testing.T) {
+	var stored []byte
+	cs := NewCommandSet(newMetadataChannel(&stored))
+
+	m, err := cs.GetMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, "", m.Name())
+	assert.Equal(t, []uint32{}, m.Paths())
+}
+
+func TestAccountList_Save(t *testing.T) {
+	var stored []byte
+	cs := NewCommandSet(newMetadataChannel(&stored))
+
+	al, err := cs.LoadAccountList()
+	assert.NoError(t, err)
+
+	assert.NoError(t, al.Rename("123"))
+	assert.NoError(t, al.Add(0x7a28, 0x00, 0x7a29, 0x04, 0x05, 0x06, 0x07, 0x08))
+	al.Remove(0x08)
+	assert.NoError(t, al.Save())
+	assert.Equal(t, []byte{0x23, 0x31, 0x32, 0x33, 0x00, 0x00, 0x04, 0x03, 0x82, 0x7a, 0x28, 0x01}, stored)
+
+	m, err := cs.GetMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, "123", m.Name())
+	assert.Equal(t, al.Indexes(), m.Paths())
+
+	// saving again without conflicts
+	assert.NoError(t, al.Add(0x01))
+	assert.NoError(t, al.Save())
+}
+
+func TestAccountList_SaveV2(t *testing.T) {
+	chat := &types.Account{Type: types.AccountTypeChat, Path: []uint32{0x8000002b, 0x8000003c, 0x8000062d, 0x80000000, 0}}
+	labeled := &types.Account{Type: types.AccountTypeEthereum, Path: []uint32{0x8000002c, 0x8000003c, 0x80000000, 0, 3}, Label: "main"}
+	initial, err := types.NewMetadataV2("card", time.Unix(1700000000, 0).UTC(), []*types.Account{chat, labeled})
+	assert.NoError(t, err)
+
+	stored := initial.Serialize()
+	cs := NewCommandSet(newMetadataChannel(&stored))
+
+	al, err := cs.LoadAccountList()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{3}, al.Indexes())
+
+	assert.NoError(t, al.Add(3, 0, 1, 2))
+	al.Remove(1)
+	assert.NoError(t, al.Save())
+
+	m, err := cs.GetMetadata()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(types.MetadataVersion2), m.Version())
+	assert.Equal(t, []uint32{0, 2, 3}, al.Indexes())
+
+	reloaded, err := cs.LoadAccountList()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{0, 2, 3}, reloaded.Indexes())
+	assert.Equal(t, "main", m.Account(labeled.Path).Label)
+	assert.NotNil(t, m.Account(chat.Path))
+
+	reloaded.SetAccountGroup(types.AccountTypeChat, chat.Path[:len(chat.Path)-1])
+	assert.Equal(t, []uint32{0}, reloaded.Indexes())
+}
+
+func TestAccountList_SaveConflict(t *testing.T) {
+	var stored []byte
+	cs := NewCommandSet(newMetadataChannel(&stored))
+
+	first, err := cs.LoadAccountList()
+	assert.NoError(t, err)
+	second, err := cs.LoadAccountList()
+	assert.NoError(t, err)
+
+	assert.NoError(t, first.Add(1))
+	assert.NoError(t, first.Save())
+
+	assert.NoError(t, second.Add(2))
+	assert.Equal(t, ErrMetadataConflict, second.Save())
+
+	assert.NoError(t, second.Reload())
+	assert.NoError(t, second.Add(2))
+	assert.NoError(t, second.Save())
+
+	m, err := types.ParseMetadata(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2}, m.Paths())
+}
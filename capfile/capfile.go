@@ -0,0 +1,266 @@
+// Package capfile parses JavaCard CAP files.
+package capfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// LoadFileComponents are the components sent to the card, in the order they are loaded.
+// Descriptor and Debug components are not loaded.
+var LoadFileComponents = []string{
+	"Header", "Directory", "Import", "Applet", "Class",
+	"Method", "StaticField", "Export", "ConstantPool", "RefLocation",
+}
+
+// javacard.framework package AID, used to detect the JavaCard version.
+var frameworkAID = []byte{0xA0, 0x00, 0x00, 0x00, 0x62, 0x01, 0x01}
+
+// javacard.framework versions and the JavaCard version introducing them.
+var frameworkVersions = map[string]string{
+	"1.0": "2.1",
+	"1.2": "2.2",
+	"1.3": "2.2.1",
+	"1.4": "2.2.2",
+	"1.5": "3.0.1",
+	"1.6": "3.0.4",
+	"1.8": "3.1",
+}
+
+// ErrMissingComponent is returned when a required component is not in the CAP file.
+type ErrMissingComponent struct {
+	name string
+}
+
+func (e *ErrMissingComponent) Error() string {
+	return fmt.Sprintf("missing %s component", e.name)
+}
+
+// CapFile is a parsed CAP file.
+// Only the components describing the package are parsed, the others are kept as they are to be loaded.
+type CapFile struct {
+	Header    *Header
+	Directory *Directory
+	Applets   []*Applet
+	Imports   []*PackageInfo
+
+	components map[string]*zip.File
+}
+
+// Open parses the CAP file f.
+func Open(f *os.File) (*CapFile, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(f, fi.Size())
+}
+
+// Parse parses a CAP file of the specified size, read from r.
+func Parse(r io.ReaderAt, size int64) (*CapFile, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CapFile{
+		components: make(map[string]*zip.File),
+	}
+
+	for _, item := range z.File {
+		if !strings.HasSuffix(item.Name, ".cap") {
+			continue
+		}
+
+		name := strings.TrimSuffix(path.Base(item.Name), ".cap")
+		if _, ok := c.components[name]; ok {
+			return nil, fmt.Errorf("duplicate %s component", name)
+		}
+
+		c.components[name] = item
+	}
+
+	data, err := c.component("Header")
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Header, err = parseHeader(data); err != nil {
+		return nil, err
+	}
+
+	if data, err = c.component("Directory"); err != nil {
+		return nil, err
+	}
+
+	if c.Directory, err = parseDirectory(data, c.Header); err != nil {
+		return nil, err
+	}
+
+	if data, err = c.component("Import"); err != nil {
+		return nil, err
+	}
+
+	if c.Imports, err = parseImports(data); err != nil {
+		return nil, err
+	}
+
+	if len(c.Imports) != int(c.Directory.ImportCount) {
+		return nil, fmt.Errorf("directory declares %d imports, found %d", c.Directory.ImportCount, len(c.Imports))
+	}
+
+	c.Applets = make([]*Applet, 0)
+	if _, ok := c.components["Applet"]; ok {
+		if data, err = c.component("Applet"); err != nil {
+			return nil, err
+		}
+
+		if c.Applets, err = parseApplets(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(c.Applets) != int(c.Directory.AppletCount) {
+		return nil, fmt.Errorf("directory declares %d applets, found %d", c.Directory.AppletCount, len(c.Applets))
+	}
+
+	return c, nil
+}
+
+// PackageAID returns the AID of the package.
+func (c *CapFile) PackageAID() []byte {
+	return c.Header.Package.AID
+}
+
+// PackageVersion returns the version of the package as major.minor.
+func (c *CapFile) PackageVersion() string {
+	return c.Header.Package.Version()
+}
+
+// AppletAIDs returns the AIDs of the applets defined in the package.
+func (c *CapFile) AppletAIDs() [][]byte {
+	aids := make([][]byte, 0, len(c.Applets))
+	for _, a := range c.Applets {
+		aids = append(aids, a.AID)
+	}
+
+	return aids
+}
+
+// JavaCardVersion returns the minimum JavaCard version needed by the package,
+// based on the imported version of javacard.framework. It returns an empty string if it's unknown.
+func (c *CapFile) JavaCardVersion() string {
+	for _, p := range c.Imports {
+		if bytes.Equal(p.AID, frameworkAID) {
+			return frameworkVersions[p.Version()]
+		}
+	}
+
+	return ""
+}
+
+// LoadFileDataBlock returns the concatenation of the components sent to the card with the LOAD command.
+func (c *CapFile) LoadFileDataBlock() ([]byte, error) {
+	return ioutil.ReadAll(c.LoadFileDataBlockReader())
+}
+
+// LoadFileDataBlockSize returns the size of the load file data block.
+func (c *CapFile) LoadFileDataBlockSize() int {
+	size := 0
+	for _, f := range c.loadFileComponents() {
+		size += int(f.UncompressedSize64)
+	}
+
+	return size
+}
+
+// LoadFileDataBlockReader returns a reader of the load file data block.
+// Components are decompressed one at a time while reading.
+func (c *CapFile) LoadFileDataBlockReader() io.Reader {
+	return &componentsReader{files: c.loadFileComponents()}
+}
+
+// LoadFileDataBlockHash returns the hash of the load file data block using the specified hash function,
+// usually sha1.New or sha256.New.
+func (c *CapFile) LoadFileDataBlockHash(newHash func() hash.Hash) ([]byte, error) {
+	h := newHash()
+	if _, err := io.Copy(h, c.LoadFileDataBlockReader()); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+func (c *CapFile) loadFileComponents() []*zip.File {
+	files := make([]*zip.File, 0, len(LoadFileComponents))
+	for _, name := range LoadFileComponents {
+		if f, ok := c.components[name]; ok {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+func (c *CapFile) component(name string) ([]byte, error) {
+	f, ok := c.components[name]
+	if !ok {
+		return nil, &ErrMissingComponent{name}
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+// componentsReader reads the concatenation of zip files, opening each file only when the previous one is done.
+type componentsReader struct {
+	files   []*zip.File
+	current io.ReadCloser
+}
+
+func (r *componentsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.files) == 0 {
+				return 0, io.EOF
+			}
+
+			rc, err := r.files[0].Open()
+			if err != nil {
+				return 0, err
+			}
+
+			r.current = rc
+			r.files = r.files[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			if err := r.current.Close(); err != nil {
+				return n, err
+			}
+
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+
+			return n, nil
+		}
+
+		return n, err
+	}
+}
@@ -0,0 +1,99 @@
+package capfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+var testComponents = map[string]string{
+	"Header":    "010023DECAFFED020204010307A000000804000111696D2F7374617475732F6B657963617264",
+	"Directory": "020021002300210015000C00030000000000000000000000000000000000000000020100",
+	"Import":    "04001502000107A0000000620001060107A0000000620101",
+	"Applet":    "03000C0108A0000008040001010010",
+	"Class":     "060003AABBCC",
+}
+
+func buildCapFile(t *testing.T, components map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	z := zip.NewWriter(buf)
+
+	f, err := z.Create("META-INF/MANIFEST.MF")
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("Manifest-Version: 1.0\n"))
+	assert.NoError(t, err)
+
+	for name, data := range components {
+		f, err := z.Create("im/status/keycard/javacard/" + name + ".cap")
+		assert.NoError(t, err)
+		_, err = f.Write(hexutils.HexToBytes(data))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, z.Close())
+
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	data := buildCapFile(t, testComponents)
+	c, err := Parse(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint8(2), c.Header.FormatMajorVersion)
+	assert.Equal(t, uint8(2), c.Header.FormatMinorVersion)
+	assert.Equal(t, "im/status/keycard", c.Header.PackageName)
+	assert.Equal(t, "A0000008040001", hexutils.BytesToHex(c.PackageAID()))
+	assert.Equal(t, "3.1", c.PackageVersion())
+
+	assert.Len(t, c.Directory.ComponentSizes, 12)
+	assert.Equal(t, uint16(0x23), c.Directory.ComponentSizes[0])
+
+	assert.Equal(t, [][]byte{hexutils.HexToBytes("A000000804000101")}, c.AppletAIDs())
+	assert.Equal(t, uint16(0x10), c.Applets[0].InstallMethodOffset)
+
+	assert.Len(t, c.Imports, 2)
+	assert.Equal(t, "1.0", c.Imports[0].Version())
+	assert.Equal(t, "3.0.4", c.JavaCardVersion())
+
+	block, err := c.LoadFileDataBlock()
+	assert.NoError(t, err)
+	expected := testComponents["Header"] + testComponents["Directory"] + testComponents["Import"] +
+		testComponents["Applet"] + testComponents["Class"]
+	assert.Equal(t, hexutils.HexToBytes(expected), block)
+	assert.Equal(t, len(block), c.LoadFileDataBlockSize())
+
+	hash, err := c.LoadFileDataBlockHash(sha256.New)
+	assert.NoError(t, err)
+	expectedHash := sha256.Sum256(block)
+	assert.Equal(t, expectedHash[:], hash)
+}
+
+func TestParse_Errors(t *testing.T) {
+	components := map[string]string{
+		"Header": testComponents["Header"],
+	}
+
+	data := buildCapFile(t, components)
+	_, err := Parse(bytes.NewReader(data), int64(len(data)))
+	assert.Equal(t, &ErrMissingComponent{"Directory"}, err)
+
+	components["Header"] = "010023CAFEBABE020204010307A000000804000111696D2F7374617475732F6B657963617264"
+	data = buildCapFile(t, components)
+	_, err = Parse(bytes.NewReader(data), int64(len(data)))
+	assert.Equal(t, ErrBadMagic, err)
+
+	// applet declared in the directory but no Applet component
+	components = map[string]string{}
+	for name, c := range testComponents {
+		components[name] = c
+	}
+	delete(components, "Applet")
+	data = buildCapFile(t, components)
+	_, err = Parse(bytes.NewReader(data), int64(len(data)))
+	assert.EqualError(t, err, "directory declares 1 applets, found 0")
+}
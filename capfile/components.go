@@ -0,0 +1,269 @@
+package capfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Component tags as defined in the JavaCard virtual machine specifications.
+const (
+	TagHeader    = 0x01
+	TagDirectory = 0x02
+	TagApplet    = 0x03
+	TagImport    = 0x04
+)
+
+const headerMagic = 0xDECAFFED
+
+var ErrBadMagic = errors.New("bad header magic number")
+
+// PackageInfo identifies a package with its AID and version.
+type PackageInfo struct {
+	MinorVersion uint8
+	MajorVersion uint8
+	AID          []byte
+}
+
+// Version returns the package version as major.minor.
+func (p *PackageInfo) Version() string {
+	return fmt.Sprintf("%d.%d", p.MajorVersion, p.MinorVersion)
+}
+
+// Header is the Header component of a CAP file.
+type Header struct {
+	// FormatMinorVersion and FormatMajorVersion are the version of the CAP file format.
+	FormatMinorVersion uint8
+	FormatMajorVersion uint8
+	Flags              uint8
+	Package            *PackageInfo
+	// PackageName is only present in CAP files with format 2.2 or later.
+	PackageName string
+}
+
+// Directory is the Directory component of a CAP file.
+type Directory struct {
+	ComponentSizes []uint16
+	ImportCount    uint8
+	AppletCount    uint8
+	CustomCount    uint8
+}
+
+// Applet describes an applet defined in the Applet component of a CAP file.
+type Applet struct {
+	AID                 []byte
+	InstallMethodOffset uint16
+}
+
+type componentReader struct {
+	*bytes.Reader
+}
+
+// newComponentReader checks the tag and size of a component and returns a reader of its info.
+func newComponentReader(name string, tag uint8, data []byte) (*componentReader, error) {
+	if len(data) < 3 {
+		return nil, fmt.Errorf("%s component too short", name)
+	}
+
+	if data[0] != tag {
+		return nil, fmt.Errorf("%s component has tag %d, expected %d", name, data[0], tag)
+	}
+
+	size := int(binary.BigEndian.Uint16(data[1:3]))
+	if size != len(data)-3 {
+		return nil, fmt.Errorf("%s component has size %d, expected %d", name, len(data)-3, size)
+	}
+
+	return &componentReader{bytes.NewReader(data[3:])}, nil
+}
+
+func (r *componentReader) readUint8() (uint8, error) {
+	return r.ReadByte()
+}
+
+func (r *componentReader) readUint16() (uint16, error) {
+	var n uint16
+	err := binary.Read(r, binary.BigEndian, &n)
+	return n, err
+}
+
+func (r *componentReader) readBytes(n int) ([]byte, error) {
+	data := make([]byte, n)
+	if n == 0 {
+		return data, nil
+	}
+
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (r *componentReader) readPackageInfo() (*PackageInfo, error) {
+	data, err := r.readBytes(3)
+	if err != nil {
+		return nil, err
+	}
+
+	aid, err := r.readBytes(int(data[2]))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PackageInfo{
+		MinorVersion: data[0],
+		MajorVersion: data[1],
+		AID:          aid,
+	}, nil
+}
+
+func parseHeader(data []byte) (*Header, error) {
+	r, err := newComponentReader("Header", TagHeader, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var magic uint32
+	if err := binary.Read(r, binary.BigEndian, &magic); err != nil {
+		return nil, err
+	}
+
+	if magic != headerMagic {
+		return nil, ErrBadMagic
+	}
+
+	versionAndFlags, err := r.readBytes(3)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Header{
+		FormatMinorVersion: versionAndFlags[0],
+		FormatMajorVersion: versionAndFlags[1],
+		Flags:              versionAndFlags[2],
+	}
+
+	if h.Package, err = r.readPackageInfo(); err != nil {
+		return nil, err
+	}
+
+	if r.Len() > 0 {
+		nameLength, err := r.readUint8()
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := r.readBytes(int(nameLength))
+		if err != nil {
+			return nil, err
+		}
+
+		h.PackageName = string(name)
+	}
+
+	return h, nil
+}
+
+// parseDirectory parses the Directory component. The number of component sizes depends
+// on the CAP file format: 11 for format 2.1 and 12 for later formats, which add the Debug component.
+func parseDirectory(data []byte, header *Header) (*Directory, error) {
+	r, err := newComponentReader("Directory", TagDirectory, data)
+	if err != nil {
+		return nil, err
+	}
+
+	sizesCount := 12
+	if header.FormatMajorVersion == 2 && header.FormatMinorVersion < 2 {
+		sizesCount = 11
+	}
+
+	d := &Directory{
+		ComponentSizes: make([]uint16, sizesCount),
+	}
+
+	for i := range d.ComponentSizes {
+		if d.ComponentSizes[i], err = r.readUint16(); err != nil {
+			return nil, err
+		}
+	}
+
+	// static field size info: image size, array init count, array init size
+	if _, err := r.readBytes(6); err != nil {
+		return nil, err
+	}
+
+	counts, err := r.readBytes(3)
+	if err != nil {
+		return nil, err
+	}
+
+	d.ImportCount = counts[0]
+	d.AppletCount = counts[1]
+	d.CustomCount = counts[2]
+
+	return d, nil
+}
+
+func parseApplets(data []byte) ([]*Applet, error) {
+	r, err := newComponentReader("Applet", TagApplet, data)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := r.readUint8()
+	if err != nil {
+		return nil, err
+	}
+
+	applets := make([]*Applet, 0, count)
+	for i := 0; i < int(count); i++ {
+		aidLength, err := r.readUint8()
+		if err != nil {
+			return nil, err
+		}
+
+		aid, err := r.readBytes(int(aidLength))
+		if err != nil {
+			return nil, err
+		}
+
+		offset, err := r.readUint16()
+		if err != nil {
+			return nil, err
+		}
+
+		applets = append(applets, &Applet{
+			AID:                 aid,
+			InstallMethodOffset: offset,
+		})
+	}
+
+	return applets, nil
+}
+
+func parseImports(data []byte) ([]*PackageInfo, error) {
+	r, err := newComponentReader("Import", TagImport, data)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := r.readUint8()
+	if err != nil {
+		return nil, err
+	}
+
+	imports := make([]*PackageInfo, 0, count)
+	for i := 0; i < int(count); i++ {
+		p, err := r.readPackageInfo()
+		if err != nil {
+			return nil, err
+		}
+
+		imports = append(imports, p)
+	}
+
+	return imports, nil
+}
@@ -1,12 +1,22 @@
 package keycard
 
 import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/status-im/keycard-go/apdu"
 	"github.com/status-im/keycard-go/globalplatform"
 	"github.com/status-im/keycard-go/identifiers"
 	"github.com/status-im/keycard-go/types"
 )
 
+var (
+	ErrInvalidSignature     = errors.New("invalid signature")
+	ErrSignatureKeyMismatch = errors.New("signature not made with the cash key")
+)
+
 type CashCommandSet struct {
 	c                   types.Channel
 	CashApplicationInfo *types.CashApplicationInfo
@@ -51,6 +61,21 @@ func (cs *CashCommandSet) Sign(data []byte) (*types.Signature, error) {
 	return types.ParseSignature(data, resp.Data)
 }
 
+// VerifySignature checks that sig is a valid signature of hash made with the cash key read by Select.
+func (cs *CashCommandSet) VerifySignature(hash []byte, sig *types.Signature) error {
+	pubKey := cs.CashApplicationInfo.PublicKey
+	if len(pubKey) == 0 || !bytes.Equal(sig.PubKey(), pubKey) {
+		return ErrSignatureKeyMismatch
+	}
+
+	rs := append(common.LeftPadBytes(sig.R(), 32), common.LeftPadBytes(sig.S(), 32)...)
+	if !ethcrypto.VerifySignature(pubKey, hash, rs) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
 func (cs *CashCommandSet) checkOK(resp *apdu.Response, err error, allowedResponses ...uint16) error {
 	if err != nil {
 		return err
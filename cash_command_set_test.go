@@ -0,0 +1,37 @@
+package keycard
+
+import (
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCashCommandSet_VerifySignature(t *testing.T) {
+	key, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+
+	cs := NewCashCommandSet(newRecordingChannel())
+	cs.CashApplicationInfo.PublicKey = ethcrypto.FromECDSAPub(&key.PublicKey)
+
+	hash := ethcrypto.Keccak256([]byte("payment"))
+	raw, err := ethcrypto.Sign(hash, key)
+	require.NoError(t, err)
+	sig, err := types.ParseRecoverableSignature(hash, raw)
+	require.NoError(t, err)
+
+	assert.NoError(t, cs.VerifySignature(hash, sig))
+
+	// the signature recovers a different key for another hash
+	otherHash := ethcrypto.Keccak256([]byte("other"))
+	otherSig, err := types.ParseRecoverableSignature(otherHash, raw)
+	require.NoError(t, err)
+	assert.Equal(t, ErrSignatureKeyMismatch, cs.VerifySignature(otherHash, otherSig))
+
+	otherKey, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+	cs.CashApplicationInfo.PublicKey = ethcrypto.FromECDSAPub(&otherKey.PublicKey)
+	assert.Equal(t, ErrSignatureKeyMismatch, cs.VerifySignature(hash, sig))
+}
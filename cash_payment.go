@@ -0,0 +1,158 @@
+package keycard
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	ErrInvalidPaymentIntent = errors.New("payment intent must have a positive amount of at most 256 bits")
+	ErrMissingChainID       = errors.New("chain ID not set in the payment intent nor in the cash public data")
+)
+
+// erc20TransferSelector is the selector of the ERC-20 transfer(address,uint256) method.
+var erc20TransferSelector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+
+// PaymentIntent is a payment made with the cash key.
+type PaymentIntent struct {
+	// ChainID is the chain of the payment. If nil, the chain ID of the cash public data is used.
+	ChainID *big.Int
+	To      common.Address
+	// Amount is in wei for native payments, or in the smallest unit of the token.
+	Amount *big.Int
+	// Token is the ERC-20 contract of the payment. If zero, the token of the cash public data is used,
+	// and the payment is in the native currency if that's zero too.
+	Token common.Address
+}
+
+// TxParamsProvider returns the chain state needed to build a transaction, usually querying a node.
+type TxParamsProvider interface {
+	Nonce(chainID *big.Int, from common.Address) (uint64, error)
+	// GasFees returns the max priority fee and the max fee per gas.
+	GasFees(chainID *big.Int) (*big.Int, *big.Int, error)
+	EstimateGas(chainID *big.Int, from, to common.Address, value *big.Int, data []byte) (uint64, error)
+}
+
+// Payment is a signed payment transaction.
+type Payment struct {
+	From common.Address
+	Tx   *ethtypes.Transaction
+	// RawTx is the encoded transaction, ready to be broadcast.
+	RawTx []byte
+}
+
+// Pay selects the cash applet, builds an EIP-1559 transaction for the intent and signs it on the card.
+func (cs *CashCommandSet) Pay(intent *PaymentIntent, provider TxParamsProvider) (*Payment, error) {
+	if intent.Amount == nil || intent.Amount.Sign() <= 0 || intent.Amount.BitLen() > 256 {
+		return nil, ErrInvalidPaymentIntent
+	}
+
+	if err := cs.Select(); err != nil {
+		return nil, err
+	}
+
+	from, err := cs.CashApplicationInfo.Address()
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, token := intent.ChainID, intent.Token
+	if chainID == nil || token == (common.Address{}) {
+		publicData, err := cs.CashApplicationInfo.ParsePublicData()
+		if err != nil {
+			return nil, err
+		}
+
+		if chainID == nil {
+			if publicData.ChainID == 0 {
+				return nil, ErrMissingChainID
+			}
+
+			chainID = new(big.Int).SetUint64(publicData.ChainID)
+		}
+
+		if token == (common.Address{}) {
+			token = publicData.Token
+		}
+	}
+
+	to, value, data := intent.To, intent.Amount, []byte{}
+	if token != (common.Address{}) {
+		to, value, data = token, big.NewInt(0), encodeERC20Transfer(intent.To, intent.Amount)
+	}
+
+	nonce, err := provider.Nonce(chainID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	tipCap, feeCap, err := provider.GasFees(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	gas, err := provider.EstimateGas(chainID, from, to, value, data)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &to,
+		Value:     value,
+		Data:      data,
+	})
+
+	signer := ethtypes.NewLondonSigner(chainID)
+	hash := signer.Hash(tx)
+
+	sig, err := cs.Sign(hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cs.VerifySignature(hash.Bytes(), sig); err != nil {
+		return nil, err
+	}
+
+	rsv := append(common.LeftPadBytes(sig.R(), 32), common.LeftPadBytes(sig.S(), 32)...)
+	rsv = append(rsv, sig.V())
+
+	signed, err := tx.WithSignature(signer, rsv)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	payer, err := ethtypes.Sender(signer, signed)
+	if err != nil {
+		return nil, err
+	}
+
+	if payer != from {
+		return nil, ErrSignatureKeyMismatch
+	}
+
+	return &Payment{
+		From:  payer,
+		Tx:    signed,
+		RawTx: raw,
+	}, nil
+}
+
+func encodeERC20Transfer(to common.Address, amount *big.Int) []byte {
+	data := append([]byte{}, erc20TransferSelector...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+}
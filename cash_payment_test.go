@@ -0,0 +1,134 @@
+package keycard
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/globalplatform"
+	"github.com/status-im/keycard-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cashCard simulates the cash applet.
+type cashCard struct {
+	t          *testing.T
+	key        *ecdsa.PrivateKey
+	publicData []byte
+}
+
+func (cc *cashCard) channel() *scriptedChannel {
+	return newScriptedChannel().on(globalplatform.InsSelect, cc.selectApplet).on(InsSign, cc.sign)
+}
+
+func (cc *cashCard) selectApplet(cmd *apdu.Command) *apdu.Response {
+	pubKey := ethcrypto.FromECDSAPub(&cc.key.PublicKey)
+	data := append([]byte{0x80, byte(len(pubKey))}, pubKey...)
+	data = append(data, 0x82, byte(len(cc.publicData)))
+	data = append(data, cc.publicData...)
+	data = append(data, 0x02, 0x02, 0x03, 0x01)
+	return response(append([]byte{0xA4, byte(len(data))}, data...), apdu.SwOK)
+}
+
+func (cc *cashCard) sign(cmd *apdu.Command) *apdu.Response {
+	sig, err := ethcrypto.Sign(cmd.Data, cc.key)
+	require.NoError(cc.t, err)
+	return response(append([]byte{0x80, 0x41}, sig...), apdu.SwOK)
+}
+
+type stubTxParamsProvider struct {
+	estimated []byte
+}
+
+func (p *stubTxParamsProvider) Nonce(chainID *big.Int, from common.Address) (uint64, error) {
+	return 7, nil
+}
+
+func (p *stubTxParamsProvider) GasFees(chainID *big.Int) (*big.Int, *big.Int, error) {
+	return big.NewInt(1000000000), big.NewInt(30000000000), nil
+}
+
+func (p *stubTxParamsProvider) EstimateGas(chainID *big.Int, from, to common.Address, value *big.Int, data []byte) (uint64, error) {
+	p.estimated = data
+	return 21000, nil
+}
+
+func newCashCard(t *testing.T, publicData *types.CashPublicData) *cashCard {
+	key, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+
+	data, err := publicData.Encode()
+	require.NoError(t, err)
+
+	return &cashCard{t: t, key: key, publicData: data}
+}
+
+func TestCashCommandSet_Pay(t *testing.T) {
+	c := newCashCard(t, &types.CashPublicData{ChainID: 10})
+	cs := NewCashCommandSet(c.channel())
+	provider := &stubTxParamsProvider{}
+	merchant := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	payment, err := cs.Pay(&PaymentIntent{To: merchant, Amount: big.NewInt(1000)}, provider)
+	require.NoError(t, err)
+	assert.Equal(t, ethcrypto.PubkeyToAddress(c.key.PublicKey), payment.From)
+
+	tx := new(ethtypes.Transaction)
+	require.NoError(t, tx.UnmarshalBinary(payment.RawTx))
+	assert.Equal(t, uint8(ethtypes.DynamicFeeTxType), tx.Type())
+	assert.Equal(t, big.NewInt(10), tx.ChainId())
+	assert.Equal(t, uint64(7), tx.Nonce())
+	assert.Equal(t, merchant, *tx.To())
+	assert.Equal(t, big.NewInt(1000), tx.Value())
+
+	sender, err := ethtypes.Sender(ethtypes.NewLondonSigner(tx.ChainId()), tx)
+	require.NoError(t, err)
+	assert.Equal(t, payment.From, sender)
+}
+
+func TestCashCommandSet_PayERC20(t *testing.T) {
+	c := newCashCard(t, &types.CashPublicData{})
+	cs := NewCashCommandSet(c.channel())
+	provider := &stubTxParamsProvider{}
+	token := common.HexToAddress("0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85")
+	merchant := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	intent := &PaymentIntent{To: merchant, Amount: big.NewInt(5000000), Token: token}
+	_, err := cs.Pay(intent, provider)
+	assert.Equal(t, ErrMissingChainID, err)
+
+	intent.ChainID = big.NewInt(10)
+	payment, err := cs.Pay(intent, provider)
+	require.NoError(t, err)
+
+	expectedData := common.FromHex("a9059cbb00000000000000000000000000000000000000000000000000000000000000aa00000000000000000000000000000000000000000000000000000000004c4b40")
+	assert.Equal(t, token, *payment.Tx.To())
+	assert.Equal(t, 0, payment.Tx.Value().Sign())
+	assert.Equal(t, expectedData, payment.Tx.Data())
+	assert.Equal(t, expectedData, provider.estimated)
+
+	_, err = cs.Pay(&PaymentIntent{To: merchant, Amount: big.NewInt(0)}, provider)
+	assert.Equal(t, ErrInvalidPaymentIntent, err)
+
+	tooLarge := new(big.Int).Lsh(big.NewInt(1), 256)
+	_, err = cs.Pay(&PaymentIntent{ChainID: big.NewInt(10), To: merchant, Amount: tooLarge, Token: token}, provider)
+	assert.Equal(t, ErrInvalidPaymentIntent, err)
+}
+
+func TestCashCommandSet_PayPublicDataToken(t *testing.T) {
+	token := common.HexToAddress("0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85")
+	c := newCashCard(t, &types.CashPublicData{ChainID: 10, Token: token})
+	cs := NewCashCommandSet(c.channel())
+	merchant := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	payment, err := cs.Pay(&PaymentIntent{To: merchant, Amount: big.NewInt(5000000)}, &stubTxParamsProvider{})
+	require.NoError(t, err)
+	assert.Equal(t, token, *payment.Tx.To())
+	assert.Equal(t, 0, payment.Tx.Value().Sign())
+	assert.Equal(t, erc20TransferSelector, payment.Tx.Data()[:4])
+}
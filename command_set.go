@@ -7,9 +7,11 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/status-im/keycard-go/apdu"
 	"github.com/status-im/keycard-go/crypto"
+	"github.com/status-im/keycard-go/derivationpath"
 	"github.com/status-im/keycard-go/globalplatform"
 	"github.com/status-im/keycard-go/identifiers"
 	"github.com/status-im/keycard-go/types"
@@ -17,6 +19,7 @@ import (
 
 var ErrNoAvailablePairingSlots = errors.New("no available pairing slots")
 var ErrBadChecksumSize = errors.New("bad checksum size")
+var ErrUnknownKeyPath = errors.New("current key path is unknown and cannot be read from the card")
 
 type WrongPINError struct {
 	RemainingAttempts int
@@ -39,16 +42,44 @@ type CommandSet struct {
 	sc              *SecureChannel
 	ApplicationInfo *types.ApplicationInfo
 	PairingInfo     *types.PairingInfo
+	// keyPath is the absolute path of the current key on the card, nil if unknown.
+	keyPath *derivationpath.Path
+	// pinlessPath is the absolute pinless path set with SetPinlessPath, nil if unknown.
+	pinlessPath *derivationpath.Path
+	// instanceIndex is the index of the Keycard instance selected by Select.
+	instanceIndex int
+	// invalidated is set by Invalidate, possibly from another goroutine. The state is reset before the next command.
+	invalidated int32
 }
 
+// NewCommandSet returns a CommandSet for the default Keycard instance.
 func NewCommandSet(c types.Channel) *CommandSet {
 	return &CommandSet{
 		c:               c,
 		sc:              NewSecureChannel(c),
 		ApplicationInfo: &types.ApplicationInfo{},
+		instanceIndex:   identifiers.KeycardDefaultInstanceIndex,
 	}
 }
 
+// NewCommandSetForInstance returns a CommandSet for the Keycard instance with the specified index.
+// Each instance has its own keys, pairings and PIN.
+func NewCommandSetForInstance(c types.Channel, index int) (*CommandSet, error) {
+	if _, err := identifiers.KeycardInstanceAID(index); err != nil {
+		return nil, err
+	}
+
+	cs := NewCommandSet(c)
+	cs.instanceIndex = index
+
+	return cs, nil
+}
+
+// InstanceIndex returns the index of the Keycard instance used by the CommandSet.
+func (cs *CommandSet) InstanceIndex() int {
+	return cs.instanceIndex
+}
+
 func (cs *CommandSet) SetPairingInfo(key []byte, index int) {
 	cs.PairingInfo = &types.PairingInfo{
 		Key:   key,
@@ -57,14 +88,14 @@ func (cs *CommandSet) SetPairingInfo(key []byte, index int) {
 }
 
 func (cs *CommandSet) Select() error {
-	instanceAID, err := identifiers.KeycardInstanceAID(identifiers.KeycardDefaultInstanceIndex)
+	instanceAID, err := identifiers.KeycardInstanceAID(cs.instanceIndex)
 	if err != nil {
 		return err
 	}
 
 	cmd := globalplatform.NewCommandSelect(instanceAID)
 	cmd.SetLe(0)
-	resp, err := cs.c.Send(cmd)
+	resp, err := cs.send(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return err
 	}
@@ -75,6 +106,8 @@ func (cs *CommandSet) Select() error {
 	}
 
 	cs.ApplicationInfo = appInfo
+	cs.keyPath = nil
+	cs.pinlessPath = nil
 
 	if cs.ApplicationInfo.HasSecureChannelCapability() {
 		err = cs.sc.GenerateSecret(cs.ApplicationInfo.SecureChannelPublicKey)
@@ -88,14 +121,48 @@ func (cs *CommandSet) Select() error {
 	return nil
 }
 
+// Invalidate forgets the state of the selected card, like the open secure channel and the current key path.
+// It must be called when the card is removed. The pairing info is kept and Select must be called again.
+// It can be called from another goroutine, like a monitor callback: the state is reset by the goroutine
+// using the CommandSet, before sending the next command.
+func (cs *CommandSet) Invalidate() {
+	atomic.StoreInt32(&cs.invalidated, 1)
+}
+
+// resetIfInvalidated resets the state of the card if Invalidate has been called since the last reset.
+func (cs *CommandSet) resetIfInvalidated() {
+	if !atomic.CompareAndSwapInt32(&cs.invalidated, 1, 0) {
+		return
+	}
+
+	cs.sc = NewSecureChannel(cs.c)
+	cs.ApplicationInfo = &types.ApplicationInfo{}
+	cs.keyPath = nil
+	cs.pinlessPath = nil
+}
+
+// send sends cmd as it is, resetting the state first if the CommandSet has been invalidated.
+func (cs *CommandSet) send(cmd *apdu.Command) (*apdu.Response, error) {
+	cs.resetIfInvalidated()
+	return cs.c.Send(cmd)
+}
+
+// sendSecure sends cmd through the secure channel, resetting the state first if the CommandSet has been invalidated.
+func (cs *CommandSet) sendSecure(cmd *apdu.Command) (*apdu.Response, error) {
+	cs.resetIfInvalidated()
+	return cs.sc.Send(cmd)
+}
+
 func (cs *CommandSet) Init(secrets *Secrets) error {
+	cs.resetIfInvalidated()
+
 	data, err := cs.sc.OneShotEncrypt(secrets)
 	if err != nil {
 		return err
 	}
 
 	init := NewCommandInit(data)
-	resp, err := cs.c.Send(init)
+	resp, err := cs.send(init)
 
 	return cs.checkOK(resp, err)
 }
@@ -107,7 +174,7 @@ func (cs *CommandSet) Pair(pairingPass string) error {
 	}
 
 	cmd := NewCommandPairFirstStep(challenge)
-	resp, err := cs.c.Send(cmd)
+	resp, err := cs.send(cmd)
 	if resp != nil && resp.Sw == SwNoAvailablePairingSlots {
 		return ErrNoAvailablePairingSlots
 	}
@@ -128,7 +195,7 @@ func (cs *CommandSet) Pair(pairingPass string) error {
 	h.Write(secretHash[:])
 	h.Write(cardChallenge)
 	cmd = NewCommandPairFinalStep(h.Sum(nil))
-	resp, err = cs.c.Send(cmd)
+	resp, err = cs.send(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return err
 	}
@@ -150,7 +217,7 @@ func (cs *CommandSet) Pair(pairingPass string) error {
 
 func (cs *CommandSet) Unpair(index uint8) error {
 	cmd := NewCommandUnpair(index)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	return cs.checkOK(resp, err)
 }
 
@@ -161,7 +228,7 @@ func (cs *CommandSet) Identify() ([]byte, error) {
 	}
 
 	cmd := NewCommandIdentify(challenge)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 
 	if err = cs.checkOK(resp, err); err != nil {
 		return nil, err
@@ -175,8 +242,10 @@ func (cs *CommandSet) OpenSecureChannel() error {
 		return errors.New("cannot open secure channel without setting PairingInfo")
 	}
 
+	cs.resetIfInvalidated()
+
 	cmd := NewCommandOpenSecureChannel(uint8(cs.PairingInfo.Index), cs.sc.RawPublicKey())
-	resp, err := cs.c.Send(cmd)
+	resp, err := cs.send(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return err
 	}
@@ -194,7 +263,7 @@ func (cs *CommandSet) OpenSecureChannel() error {
 
 func (cs *CommandSet) GetStatus(info uint8) (*types.ApplicationStatus, error) {
 	cmd := NewCommandGetStatus(info)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return nil, err
 	}
@@ -207,12 +276,43 @@ func (cs *CommandSet) GetStatusApplication() (*types.ApplicationStatus, error) {
 }
 
 func (cs *CommandSet) GetStatusKeyPath() (*types.ApplicationStatus, error) {
-	return cs.GetStatus(P1GetStatusKeyPath)
+	status, err := cs.GetStatus(P1GetStatusKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPath, err := derivationpath.Parse(status.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.keyPath = keyPath
+
+	return status, nil
+}
+
+// KeyPath returns the absolute path of the current key as tracked locally,
+// or nil if it's unknown. The path is updated by every command changing the current key
+// and can be read again from the card with SyncKeyPath.
+func (cs *CommandSet) KeyPath() *derivationpath.Path {
+	cs.resetIfInvalidated()
+
+	if cs.keyPath == nil {
+		return nil
+	}
+
+	return cs.keyPath.Child()
+}
+
+// SyncKeyPath reads the current key path from the card.
+func (cs *CommandSet) SyncKeyPath() error {
+	_, err := cs.GetStatusKeyPath()
+	return err
 }
 
 func (cs *CommandSet) VerifyPIN(pin string) error {
 	cmd := NewCommandVerifyPIN(pin)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		if resp != nil && ((resp.Sw & 0x63C0) == 0x63C0) {
 			remainingAttempts := resp.Sw & 0x000F
@@ -228,13 +328,13 @@ func (cs *CommandSet) VerifyPIN(pin string) error {
 
 func (cs *CommandSet) ChangePIN(pin string) error {
 	cmd := NewCommandChangePIN(pin)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	return cs.checkOK(resp, err)
 }
 
 func (cs *CommandSet) UnblockPIN(puk string, newPIN string) error {
 	cmd := NewCommandUnblockPIN(puk, newPIN)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		if resp != nil && ((resp.Sw & 0x63C0) == 0x63C0) {
 			remainingAttempts := resp.Sw & 0x000F
@@ -250,7 +350,7 @@ func (cs *CommandSet) UnblockPIN(puk string, newPIN string) error {
 
 func (cs *CommandSet) ChangePUK(puk string) error {
 	cmd := NewCommandChangePUK(puk)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 
 	return cs.checkOK(resp, err)
 }
@@ -258,18 +358,20 @@ func (cs *CommandSet) ChangePUK(puk string) error {
 func (cs *CommandSet) ChangePairingSecret(password string) error {
 	secret := generatePairingToken(password)
 	cmd := NewCommandChangePairingSecret(secret)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 
 	return cs.checkOK(resp, err)
 }
 
 func (cs *CommandSet) GenerateKey() ([]byte, error) {
 	cmd := NewCommandGenerateKey()
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return nil, err
 	}
 
+	cs.keyPath = derivationpath.NewPath(derivationpath.StartingPointMaster)
+
 	return resp.Data, nil
 }
 
@@ -279,7 +381,7 @@ func (cs *CommandSet) GenerateMnemonic(checksumSize int) ([]int, error) {
 	}
 
 	cmd := NewCommandGenerateMnemonic(byte(checksumSize))
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return nil, err
 	}
@@ -301,18 +403,36 @@ func (cs *CommandSet) GenerateMnemonic(checksumSize int) ([]int, error) {
 
 func (cs *CommandSet) RemoveKey() error {
 	cmd := NewCommandRemoveKey()
-	resp, err := cs.sc.Send(cmd)
-	return cs.checkOK(resp, err)
+	resp, err := cs.sendSecure(cmd)
+	if err = cs.checkOK(resp, err); err != nil {
+		return err
+	}
+
+	cs.keyPath = nil
+	cs.pinlessPath = nil
+
+	return nil
 }
 
 func (cs *CommandSet) DeriveKey(path string) error {
+	absPath, err := cs.resolvePath(path)
+	if err != nil {
+		return err
+	}
+
 	cmd, err := NewCommandDeriveKey(path)
 	if err != nil {
 		return err
 	}
 
-	resp, err := cs.sc.Send(cmd)
-	return cs.checkOK(resp, err)
+	resp, err := cs.sendSecure(cmd)
+	if err = cs.checkOK(resp, err); err != nil {
+		return err
+	}
+
+	cs.keyPath = absPath
+
+	return nil
 }
 
 func (cs *CommandSet) ExportKey(derive bool, makeCurrent bool, onlyPublic bool, path string) ([]byte, []byte, error) {
@@ -331,56 +451,111 @@ func (cs *CommandSet) ExportKey(derive bool, makeCurrent bool, onlyPublic bool,
 		p2 = P2ExportKeyPrivateAndPublic
 	}
 
+	var absPath *derivationpath.Path
+	if derive {
+		var err error
+		if absPath, err = cs.resolvePath(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	cmd, err := NewCommandExportKey(p1, p2, path)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	err = cs.checkOK(resp, err)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if derive && makeCurrent {
+		cs.keyPath = absPath
+	}
+
 	return types.ParseExportKeyResponse(resp.Data)
 }
 
+// ExportExtendedPublicKey exports the public key and chain code of the key at path,
+// without changing the current key.
+func (cs *CommandSet) ExportExtendedPublicKey(path string) ([]byte, []byte, error) {
+	if _, err := cs.resolvePath(path); err != nil {
+		return nil, nil, err
+	}
+
+	cmd, err := NewCommandExportKey(P1ExportKeyDerive, P2ExportKeyExtendedPublic, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := cs.sendSecure(cmd)
+	if err = cs.checkOK(resp, err); err != nil {
+		return nil, nil, err
+	}
+
+	return types.ParseExportExtendedKeyResponse(resp.Data)
+}
+
 func (cs *CommandSet) SetPinlessPath(path string) error {
 	cmd, err := NewCommandSetPinlessPath(path)
 	if err != nil {
 		return err
 	}
 
-	resp, err := cs.sc.Send(cmd)
-	return cs.checkOK(resp, err)
+	resp, err := cs.sendSecure(cmd)
+	if err = cs.checkOK(resp, err); err != nil {
+		return err
+	}
+
+	if cs.pinlessPath, err = derivationpath.Parse(path); err != nil {
+		return err
+	}
+
+	if len(cs.pinlessPath.Segments) == 0 {
+		// an empty path disables pinless signing
+		cs.pinlessPath = nil
+	}
+
+	return nil
 }
 
+// Sign signs data with the current key.
+// The returned signature includes the key path if it's tracked.
 func (cs *CommandSet) Sign(data []byte) (*types.Signature, error) {
 	cmd, err := NewCommandSign(data, P1SignCurrentKey, "")
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return nil, err
 	}
 
-	return types.ParseSignature(data, resp.Data)
+	return types.ParseSignatureWithPath(data, resp.Data, pathString(cs.keyPath))
 }
 
+// SignWithPath signs data with the key at path, without changing the current key.
+// Relative paths are resolved against the current key path, and the absolute path
+// is sent to the card.
 func (cs *CommandSet) SignWithPath(data []byte, path string) (*types.Signature, error) {
-	cmd, err := NewCommandSign(data, P1SignDerive, path)
+	absPath, err := cs.resolvePath(path)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := cs.sc.Send(cmd)
+	cmd, err := NewCommandSign(data, P1SignDerive, absPath.String())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := cs.sendSecure(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return nil, err
 	}
 
-	return types.ParseSignature(data, resp.Data)
+	return types.ParseSignatureWithPath(data, resp.Data, absPath.String())
 }
 
 func (cs *CommandSet) SignPinless(data []byte) (*types.Signature, error) {
@@ -389,27 +564,29 @@ func (cs *CommandSet) SignPinless(data []byte) (*types.Signature, error) {
 		return nil, err
 	}
 
-	resp, err := cs.c.Send(cmd)
+	resp, err := cs.send(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return nil, err
 	}
 
-	return types.ParseSignature(data, resp.Data)
+	return types.ParseSignatureWithPath(data, resp.Data, pathString(cs.pinlessPath))
 }
 
 func (cs *CommandSet) LoadSeed(seed []byte) ([]byte, error) {
 	cmd := NewCommandLoadSeed(seed)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return nil, err
 	}
 
+	cs.keyPath = derivationpath.NewPath(derivationpath.StartingPointMaster)
+
 	return resp.Data, nil
 }
 
 func (cs *CommandSet) GetData(typ uint8) ([]byte, error) {
 	cmd := NewCommandGetData(typ)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
 		return nil, err
 	}
@@ -419,14 +596,59 @@ func (cs *CommandSet) GetData(typ uint8) ([]byte, error) {
 
 func (cs *CommandSet) StoreData(typ uint8, data []byte) error {
 	cmd := NewCommandStoreData(typ, data)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 	return cs.checkOK(resp, err)
 }
 
+// GetMetadata reads the wallet metadata stored in the public data slot.
+// It returns an empty Metadata if the card doesn't contain any.
+func (cs *CommandSet) GetMetadata() (*types.Metadata, error) {
+	data, err := cs.GetData(P1StoreDataPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseMetadata(data)
+}
+
+// SetMetadata stores m in the public data slot, overwriting the current content.
+func (cs *CommandSet) SetMetadata(m *types.Metadata) error {
+	return cs.StoreData(P1StoreDataPublic, m.Serialize())
+}
+
 func (cs *CommandSet) FactoryReset() error {
 	cmd := NewCommandFactoryReset()
-	resp, err := cs.c.Send(cmd)
-	return cs.checkOK(resp, err)
+	resp, err := cs.send(cmd)
+	if err = cs.checkOK(resp, err); err != nil {
+		return err
+	}
+
+	cs.keyPath = nil
+	cs.pinlessPath = nil
+
+	return nil
+}
+
+// resolvePath returns the absolute path of pathStr.
+// Relative paths are resolved against the current key path, reading it from the card if unknown.
+func (cs *CommandSet) resolvePath(pathStr string) (*derivationpath.Path, error) {
+	path, err := derivationpath.Parse(pathStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if path.IsAbsolute() {
+		return path, nil
+	}
+
+	if cs.keyPath == nil {
+		if err := cs.SyncKeyPath(); err != nil {
+			logger.Debug("cannot read current key path", "error", err)
+			return nil, ErrUnknownKeyPath
+		}
+	}
+
+	return path.Resolve(cs.keyPath)
 }
 
 func (cs *CommandSet) mutualAuthenticate() error {
@@ -436,11 +658,19 @@ func (cs *CommandSet) mutualAuthenticate() error {
 	}
 
 	cmd := NewCommandMutuallyAuthenticate(data)
-	resp, err := cs.sc.Send(cmd)
+	resp, err := cs.sendSecure(cmd)
 
 	return cs.checkOK(resp, err)
 }
 
+func pathString(path *derivationpath.Path) string {
+	if path == nil {
+		return ""
+	}
+
+	return path.String()
+}
+
 func (cs *CommandSet) checkOK(resp *apdu.Response, err error, allowedResponses ...uint16) error {
 	if err != nil {
 		return err
@@ -0,0 +1,200 @@
+package keycard
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/derivationpath"
+	"github.com/status-im/keycard-go/identifiers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyPathCard simulates the key path handling of a card.
+type keyPathCard struct {
+	t        *testing.T
+	keyPath  *derivationpath.Path
+	lastSign []byte
+}
+
+func (kc *keyPathCard) channel() *scriptedChannel {
+	return newScriptedChannel().
+		on(InsGetStatus, kc.getStatus).
+		on(InsDeriveKey, kc.derive).
+		on(InsExportKey, kc.derive).
+		on(InsSign, kc.sign)
+}
+
+func (kc *keyPathCard) getStatus(cmd *apdu.Command) *apdu.Response {
+	if kc.keyPath == nil {
+		return response(nil, 0x6985)
+	}
+
+	data := new(bytes.Buffer)
+	require.NoError(kc.t, binary.Write(data, binary.BigEndian, kc.keyPath.Segments))
+	return response(data.Bytes(), apdu.SwOK)
+}
+
+func (kc *keyPathCard) derive(cmd *apdu.Command) *apdu.Response {
+	if cmd.Ins == InsExportKey && cmd.P1&0x0F != P1ExportKeyDeriveAndMakeCurrent {
+		return response([]byte{0xA1, 0x00}, apdu.SwOK)
+	}
+
+	segments := make([]uint32, len(cmd.Data)/4)
+	require.NoError(kc.t, binary.Read(bytes.NewReader(cmd.Data), binary.BigEndian, &segments))
+
+	startingPoint := derivationpath.StartingPointMaster
+	switch cmd.P1 & 0xC0 {
+	case P1DeriveKeyFromParent:
+		startingPoint = derivationpath.StartingPointParent
+	case P1DeriveKeyFromCurrent:
+		startingPoint = derivationpath.StartingPointCurrent
+	}
+
+	p, err := derivationpath.NewPath(startingPoint, segments...).Resolve(kc.keyPath)
+	require.NoError(kc.t, err)
+	kc.keyPath = p
+
+	return response([]byte{0xA1, 0x00}, apdu.SwOK)
+}
+
+func (kc *keyPathCard) sign(cmd *apdu.Command) *apdu.Response {
+	kc.lastSign = cmd.Data[32:]
+	key, err := ethcrypto.GenerateKey()
+	require.NoError(kc.t, err)
+	sig, err := ethcrypto.Sign(cmd.Data[:32], key)
+	require.NoError(kc.t, err)
+	return response(append([]byte{0x80, 0x41}, sig...), apdu.SwOK)
+}
+
+func TestCommandSet_KeyPathTracking(t *testing.T) {
+	c := &keyPathCard{t: t, keyPath: derivationpath.MustParse("m/44'/60'/0'/0/0")}
+	cs := NewCommandSet(c.channel())
+	assert.Nil(t, cs.KeyPath())
+
+	// relative derivation reads the current path from the card first
+	assert.NoError(t, cs.DeriveKey("../1"))
+	assert.Equal(t, "m/44'/60'/0'/0/1", cs.KeyPath().String())
+	assert.True(t, c.keyPath.Equal(cs.KeyPath()))
+
+	assert.NoError(t, cs.DeriveKey("m/43'/60'/1581'"))
+	assert.Equal(t, "m/43'/60'/1581'", cs.KeyPath().String())
+
+	_, _, err := cs.ExportKey(true, true, true, "./0'/0")
+	assert.NoError(t, err)
+	assert.Equal(t, "m/43'/60'/1581'/0'/0", cs.KeyPath().String())
+
+	// export without making current doesn't change the path
+	_, _, err = cs.ExportKey(true, false, true, "../1")
+	assert.NoError(t, err)
+	assert.Equal(t, "m/43'/60'/1581'/0'/0", cs.KeyPath().String())
+	assert.True(t, c.keyPath.Equal(cs.KeyPath()))
+
+	// the returned path is a copy
+	cs.KeyPath().Segments[0] = 0
+	assert.Equal(t, "m/43'/60'/1581'/0'/0", cs.KeyPath().String())
+}
+
+func TestCommandSet_SignReportsPath(t *testing.T) {
+	c := &keyPathCard{t: t, keyPath: derivationpath.MustParse("m/44'/60'/0'/0/0")}
+	cs := NewCommandSet(c.channel())
+	hash := make([]byte, 32)
+
+	// relative paths are sent to the card as absolute paths
+	sig, err := cs.SignWithPath(hash, "../3")
+	assert.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/0'/0/3", sig.Path())
+
+	expected := new(bytes.Buffer)
+	require.NoError(t, binary.Write(expected, binary.BigEndian, derivationpath.MustParse(sig.Path()).Segments))
+	assert.Equal(t, expected.Bytes(), c.lastSign)
+
+	sig, err = cs.Sign(hash)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/0'/0/0", sig.Path())
+}
+
+func TestCommandSet_RelativePathUnknown(t *testing.T) {
+	c := &keyPathCard{t: t}
+	cs := NewCommandSet(c.channel())
+
+	assert.Equal(t, ErrUnknownKeyPath, cs.DeriveKey("../1"))
+	_, err := cs.SignWithPath(make([]byte, 32), "1")
+	assert.Equal(t, ErrUnknownKeyPath, err)
+
+	// absolute paths don't need the current path
+	sig, err := cs.SignWithPath(make([]byte, 32), "m/1")
+	assert.NoError(t, err)
+	assert.Equal(t, "m/1", sig.Path())
+
+	sig, err = cs.Sign(make([]byte, 32))
+	assert.NoError(t, err)
+	assert.Equal(t, "", sig.Path())
+}
+
+// newRecordingChannel returns a channel answering all the commands with 6A82.
+func newRecordingChannel() *scriptedChannel {
+	c := newScriptedChannel()
+	c.fallback = func(*apdu.Command) *apdu.Response {
+		return response(nil, 0x6A82)
+	}
+
+	return c
+}
+
+func TestNewCommandSetForInstance(t *testing.T) {
+	c := newRecordingChannel()
+
+	cs := NewCommandSet(c)
+	assert.Equal(t, identifiers.KeycardDefaultInstanceIndex, cs.InstanceIndex())
+
+	cs, err := NewCommandSetForInstance(c, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cs.InstanceIndex())
+	assert.Error(t, cs.Select())
+	assert.Equal(t, []byte{0xA0, 0x00, 0x00, 0x08, 0x04, 0x00, 0x01, 0x01, 0x02}, c.commands[0].Data)
+
+	_, err = NewCommandSetForInstance(c, 256)
+	assert.Equal(t, identifiers.ErrInvalidInstanceIndex, err)
+}
+
+func TestCommandSet_Invalidate(t *testing.T) {
+	cs := NewCommandSet(newRecordingChannel())
+	cs.ApplicationInfo.Installed = true
+	cs.keyPath = derivationpath.MustParse("m/44'/60'/0'/0/0")
+	cs.SetPairingInfo([]byte{0x01}, 1)
+
+	cs.Invalidate()
+	assert.Nil(t, cs.KeyPath())
+
+	// the state is reset before the next command
+	_, err := cs.GetStatusApplication()
+	assert.Error(t, err)
+	assert.False(t, cs.ApplicationInfo.Installed)
+	assert.Nil(t, cs.keyPath)
+	assert.Equal(t, 1, cs.PairingInfo.Index)
+}
+
+func TestCommandSet_InvalidateConcurrently(t *testing.T) {
+	c := &keyPathCard{t: t, keyPath: derivationpath.MustParse("m/44'/60'/0'/0/0")}
+	cs := NewCommandSet(c.channel())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			cs.Invalidate()
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		_ = cs.DeriveKey("m/44'/60'/0'/0/1")
+	}
+
+	<-done
+	cs.Invalidate()
+	assert.Nil(t, cs.KeyPath())
+}
@@ -61,6 +61,8 @@ const (
 	P2FactoryResetMagic             = 0x55
 
 	SwNoAvailablePairingSlots = 0x6A84
+	SwIncorrectP1P2           = 0x6A86
+	SwConditionsNotSatisfied  = 0x6985
 )
 
 func NewCommandInit(data []byte) *apdu.Command {
@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const hardenedStart = 0x80000000 // 2^31
+
+var (
+	ErrHardenedPublicDerivation = errors.New("cannot derive a hardened child from a public key")
+	ErrInvalidChildKey          = errors.New("invalid child key, use the next index")
+)
+
+// DeriveChildPublicKey derives the public key and chain code of the non-hardened child at index,
+// as defined by the BIP32 CKDpub function.
+// pubKey can be compressed or uncompressed. The returned public key is uncompressed.
+func DeriveChildPublicKey(pubKey, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	if index >= hardenedStart {
+		return nil, nil, ErrHardenedPublicDerivation
+	}
+
+	parent, err := unmarshalPublicKey(pubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := make([]byte, 37)
+	copy(data, crypto.CompressPubkey(parent))
+	binary.BigEndian.PutUint32(data[33:], index)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	i := mac.Sum(nil)
+
+	curve := crypto.S256()
+	il := new(big.Int).SetBytes(i[:32])
+	if il.Cmp(curve.Params().N) >= 0 {
+		return nil, nil, ErrInvalidChildKey
+	}
+
+	x, y := curve.ScalarBaseMult(i[:32])
+	x, y = curve.Add(x, y, parent.X, parent.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, nil, ErrInvalidChildKey
+	}
+
+	child := make([]byte, 65)
+	child[0] = 0x04
+	x.FillBytes(child[1:33])
+	y.FillBytes(child[33:])
+
+	return child, i[32:], nil
+}
+
+func unmarshalPublicKey(pubKey []byte) (*ecdsa.PublicKey, error) {
+	if len(pubKey) == 33 {
+		return crypto.DecompressPubkey(pubKey)
+	}
+
+	return crypto.UnmarshalPubkey(pubKey)
+}
@@ -0,0 +1,37 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveChildPublicKey(t *testing.T) {
+	// BIP32 test vector 1, m/0H -> m/0H/1
+	pubKey := hexutils.HexToBytes("035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56")
+	chainCode := hexutils.HexToBytes("47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141")
+
+	childPubKey, childChainCode, err := DeriveChildPublicKey(pubKey, chainCode, 1)
+	assert.NoError(t, err)
+
+	child, err := crypto.UnmarshalPubkey(childPubKey)
+	assert.NoError(t, err)
+
+	expectedPubKey := "03501E454BF00751F24B1B489AA925215D66AF2234E3891C3B21A52BEDB3CD711C"
+	assert.Equal(t, expectedPubKey, hexutils.BytesToHex(crypto.CompressPubkey(child)))
+
+	expectedChainCode := "2A7857631386BA23DACAC34180DD1983734E444FDBF774041578E9B6ADB37C19"
+	assert.Equal(t, expectedChainCode, hexutils.BytesToHex(childChainCode))
+
+	// uncompressed parent keys give the same result
+	parent, err := crypto.DecompressPubkey(pubKey)
+	assert.NoError(t, err)
+	childPubKey2, _, err := DeriveChildPublicKey(crypto.FromECDSAPub(parent), chainCode, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, childPubKey, childPubKey2)
+
+	_, _, err = DeriveChildPublicKey(pubKey, chainCode, hardenedStart)
+	assert.Equal(t, ErrHardenedPublicDerivation, err)
+}
@@ -0,0 +1,149 @@
+package derivationpath
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Purposes and coin types used by the structured path constructors.
+const (
+	PurposeBIP44   = 44
+	PurposeBIP49   = 49
+	PurposeBIP84   = 84
+	PurposeEIP1581 = 43
+
+	CoinTypeBitcoin        = 0
+	CoinTypeBitcoinTestnet = 1
+	CoinTypeEthereum       = 60
+
+	SubPurposeEIP1581 = 1581
+
+	ChangeExternal = 0
+	ChangeInternal = 1
+)
+
+var (
+	ErrInvalidChange       = errors.New("change must be 0 (external) or 1 (internal)")
+	ErrUnsupportedCoinType = errors.New("coin type not supported by the selected purpose")
+)
+
+// NewBIP44Path returns the path m/44'/coinType'/account'/change/index.
+func NewBIP44Path(coinType, account, change, index uint32) ([]uint32, error) {
+	return newPurposePath(PurposeBIP44, coinType, account, change, index)
+}
+
+// NewBIP49Path returns the path m/49'/coinType'/account'/change/index.
+// BIP49 (P2WPKH-nested-in-P2SH) is only defined for bitcoin, so coinType must be
+// CoinTypeBitcoin or CoinTypeBitcoinTestnet.
+func NewBIP49Path(coinType, account, change, index uint32) ([]uint32, error) {
+	if !isBitcoinCoinType(coinType) {
+		return nil, ErrUnsupportedCoinType
+	}
+
+	return newPurposePath(PurposeBIP49, coinType, account, change, index)
+}
+
+// NewBIP84Path returns the path m/84'/coinType'/account'/change/index.
+// BIP84 (native segwit) is only defined for bitcoin, so coinType must be
+// CoinTypeBitcoin or CoinTypeBitcoinTestnet.
+func NewBIP84Path(coinType, account, change, index uint32) ([]uint32, error) {
+	if !isBitcoinCoinType(coinType) {
+		return nil, ErrUnsupportedCoinType
+	}
+
+	return newPurposePath(PurposeBIP84, coinType, account, change, index)
+}
+
+// NewEIP1581Path returns the path m/43'/60'/1581'/keyType'/index used for non-wallet keys.
+// keyType 0 is used for the chat key and 1 for the encryption key.
+func NewEIP1581Path(keyType, index uint32) ([]uint32, error) {
+	if err := checkIndex("key type", keyType); err != nil {
+		return nil, err
+	}
+
+	if err := checkIndex("index", index); err != nil {
+		return nil, err
+	}
+
+	return []uint32{
+		PurposeEIP1581 + hardenedStart,
+		CoinTypeEthereum + hardenedStart,
+		SubPurposeEIP1581 + hardenedStart,
+		keyType + hardenedStart,
+		index,
+	}, nil
+}
+
+// NewAccountTemplate returns a template matching all the addresses of all the accounts
+// for the specified purpose and coin type, like m/44'/60'/*'/{0-1}/*.
+func NewAccountTemplate(purpose, coinType uint32) (*Template, error) {
+	// validate purpose and coin type using the first path
+	if _, err := NewAccountPath(purpose, coinType, 0, ChangeExternal, 0); err != nil {
+		return nil, err
+	}
+
+	return ParseTemplate(fmt.Sprintf("m/%d'/%d'/*'/{%d-%d}/*", purpose, coinType, ChangeExternal, ChangeInternal))
+}
+
+// IsHardened returns true if the index is hardened.
+func IsHardened(index uint32) bool {
+	return index >= hardenedStart
+}
+
+// Harden returns the hardened version of index.
+func Harden(index uint32) uint32 {
+	return index | hardenedStart
+}
+
+// NewAccountPath returns the path m/purpose'/coinType'/account'/change/index
+// using the constructor of the specified purpose (BIP44, BIP49 or BIP84).
+func NewAccountPath(purpose, coinType, account, change, index uint32) ([]uint32, error) {
+	switch purpose {
+	case PurposeBIP44:
+		return NewBIP44Path(coinType, account, change, index)
+	case PurposeBIP49:
+		return NewBIP49Path(coinType, account, change, index)
+	case PurposeBIP84:
+		return NewBIP84Path(coinType, account, change, index)
+	default:
+		return nil, fmt.Errorf("unsupported purpose %d", purpose)
+	}
+}
+
+func newPurposePath(purpose, coinType, account, change, index uint32) ([]uint32, error) {
+	if err := checkIndex("coin type", coinType); err != nil {
+		return nil, err
+	}
+
+	if err := checkIndex("account", account); err != nil {
+		return nil, err
+	}
+
+	if change != ChangeExternal && change != ChangeInternal {
+		return nil, ErrInvalidChange
+	}
+
+	if err := checkIndex("index", index); err != nil {
+		return nil, err
+	}
+
+	return []uint32{
+		purpose + hardenedStart,
+		coinType + hardenedStart,
+		account + hardenedStart,
+		change,
+		index,
+	}, nil
+}
+
+func checkIndex(name string, i uint32) error {
+	if i >= hardenedStart {
+		return fmt.Errorf("%s must be lower than 2^31, got %d", name, i)
+	}
+
+	return nil
+}
+
+func isBitcoinCoinType(coinType uint32) bool {
+	return coinType == CoinTypeBitcoin || coinType == CoinTypeBitcoinTestnet
+}
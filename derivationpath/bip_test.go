@@ -0,0 +1,66 @@
+package derivationpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBIP44Path(t *testing.T) {
+	path, err := NewBIP44Path(CoinTypeEthereum, 0, ChangeExternal, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/0'/0/5", Encode(path))
+
+	_, err = NewBIP44Path(CoinTypeEthereum, 0, 2, 5)
+	assert.Equal(t, ErrInvalidChange, err)
+
+	_, err = NewBIP44Path(CoinTypeEthereum, hardenedStart, ChangeExternal, 0)
+	assert.Error(t, err)
+
+	_, err = NewBIP44Path(CoinTypeEthereum, 0, ChangeExternal, hardenedStart)
+	assert.Error(t, err)
+}
+
+func TestNewBIP49Path(t *testing.T) {
+	path, err := NewBIP49Path(CoinTypeBitcoin, 1, ChangeInternal, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/49'/0'/1'/1/3", Encode(path))
+
+	_, err = NewBIP49Path(CoinTypeEthereum, 0, ChangeExternal, 0)
+	assert.Equal(t, ErrUnsupportedCoinType, err)
+}
+
+func TestNewBIP84Path(t *testing.T) {
+	path, err := NewBIP84Path(CoinTypeBitcoinTestnet, 0, ChangeExternal, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/84'/1'/0'/0/0", Encode(path))
+
+	_, err = NewBIP84Path(CoinTypeEthereum, 0, ChangeExternal, 0)
+	assert.Equal(t, ErrUnsupportedCoinType, err)
+}
+
+func TestNewEIP1581Path(t *testing.T) {
+	path, err := NewEIP1581Path(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/43'/60'/1581'/0'/0", Encode(path))
+
+	path, err = NewEIP1581Path(1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/43'/60'/1581'/1'/0", Encode(path))
+}
+
+func TestNewAccountTemplate(t *testing.T) {
+	tpl, err := NewAccountTemplate(PurposeBIP84, CoinTypeBitcoin)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/84'/0'/*'/{0-1}/*", tpl.String())
+
+	path, err := NewBIP84Path(CoinTypeBitcoin, 3, ChangeInternal, 7)
+	assert.NoError(t, err)
+	assert.True(t, tpl.Match(path))
+
+	_, err = NewAccountTemplate(PurposeBIP84, CoinTypeEthereum)
+	assert.Equal(t, ErrUnsupportedCoinType, err)
+
+	_, err = NewAccountTemplate(PurposeEIP1581, CoinTypeEthereum)
+	assert.Error(t, err)
+}
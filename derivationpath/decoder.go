@@ -158,6 +158,15 @@ func (d *decoder) saveSegment() error {
 
 func (d *decoder) parseSeparator() error {
 	b, err := d.readByte()
+	if err == io.EOF && len(d.currentToken) > 0 {
+		// path ending with a hardened segment
+		if newErr := d.saveSegment(); newErr != nil {
+			return newErr
+		}
+
+		return err
+	}
+
 	if err != nil {
 		return err
 	}
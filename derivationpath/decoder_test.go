@@ -49,6 +49,11 @@ func TestDecode(t *testing.T) {
 			expectedPath:          []uint32{1, 2147483650, 3},
 			expectedStartingPoint: StartingPointMaster,
 		},
+		{
+			path:                  "m/1/2'",
+			expectedPath:          []uint32{1, 2147483650},
+			expectedStartingPoint: StartingPointMaster,
+		},
 		{
 			path: "m/",
 			err:  fmt.Errorf("at position 2, expected number, got EOF"),
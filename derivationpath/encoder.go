@@ -8,7 +8,24 @@ import (
 )
 
 func Encode(rawPath []uint32) string {
-	segments := []string{string(rune(tokenMaster))}
+	return EncodeWithStartingPoint(StartingPointMaster, rawPath)
+}
+
+// EncodeWithStartingPoint encodes rawPath prefixing it with the token of the starting point:
+// "m" for master, ".." for parent and "." for current.
+func EncodeWithStartingPoint(startingPoint StartingPoint, rawPath []uint32) string {
+	var prefix string
+
+	switch startingPoint {
+	case StartingPointParent:
+		prefix = string([]rune{tokenDot, tokenDot})
+	case StartingPointCurrent:
+		prefix = string(rune(tokenDot))
+	default:
+		prefix = string(rune(tokenMaster))
+	}
+
+	segments := []string{prefix}
 
 	for _, i := range rawPath {
 		suffix := ""
@@ -33,3 +33,50 @@ func TestEncode(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeWithStartingPoint(t *testing.T) {
+	scenarios := []struct {
+		startingPoint StartingPoint
+		path          []uint32
+		expectedPath  string
+	}{
+		{
+			startingPoint: StartingPointMaster,
+			path:          []uint32{hardenedStart + 44, 0},
+			expectedPath:  "m/44'/0",
+		},
+		{
+			startingPoint: StartingPointCurrent,
+			path:          []uint32{},
+			expectedPath:  ".",
+		},
+		{
+			startingPoint: StartingPointCurrent,
+			path:          []uint32{1, hardenedStart + 2},
+			expectedPath:  "./1/2'",
+		},
+		{
+			startingPoint: StartingPointParent,
+			path:          []uint32{},
+			expectedPath:  "..",
+		},
+		{
+			startingPoint: StartingPointParent,
+			path:          []uint32{3},
+			expectedPath:  "../3",
+		},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("scenario %d", i), func(t *testing.T) {
+			path := EncodeWithStartingPoint(s.startingPoint, s.path)
+			assert.Equal(t, s.expectedPath, path)
+
+			// round trip
+			startingPoint, decoded, err := Decode(path)
+			assert.NoError(t, err)
+			assert.Equal(t, s.startingPoint, startingPoint)
+			assert.Equal(t, s.path, decoded)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package derivationpath
+
+import (
+	"errors"
+)
+
+var (
+	ErrNoParent            = errors.New("path has no parent")
+	ErrRelativeCurrentPath = errors.New("current path must be absolute")
+)
+
+// Path is a derivation path with its starting point.
+// Relative paths (StartingPointCurrent and StartingPointParent) can be resolved
+// against an absolute path using Resolve.
+type Path struct {
+	StartingPoint StartingPoint
+	Segments      []uint32
+}
+
+// NewPath returns a new Path with the specified starting point and segments.
+func NewPath(startingPoint StartingPoint, segments ...uint32) *Path {
+	s := make([]uint32, len(segments))
+	copy(s, segments)
+
+	return &Path{
+		StartingPoint: startingPoint,
+		Segments:      s,
+	}
+}
+
+// Parse decodes str into a Path.
+func Parse(str string) (*Path, error) {
+	startingPoint, segments, err := Decode(str)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Path{
+		StartingPoint: startingPoint,
+		Segments:      segments,
+	}, nil
+}
+
+// MustParse is like Parse but panics if str is not a valid path.
+func MustParse(str string) *Path {
+	p, err := Parse(str)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// String encodes the path keeping its starting point.
+func (p *Path) String() string {
+	return EncodeWithStartingPoint(p.StartingPoint, p.Segments)
+}
+
+// IsAbsolute returns true if the path starts from the master key.
+func (p *Path) IsAbsolute() bool {
+	return p.StartingPoint == StartingPointMaster
+}
+
+// IsHardened returns true if the last segment of the path is hardened.
+func (p *Path) IsHardened() bool {
+	return len(p.Segments) > 0 && IsHardened(p.Segments[len(p.Segments)-1])
+}
+
+// Parent returns the parent path.
+// The parent of an empty path relative to the current key is "..".
+func (p *Path) Parent() (*Path, error) {
+	if len(p.Segments) > 0 {
+		return NewPath(p.StartingPoint, p.Segments[:len(p.Segments)-1]...), nil
+	}
+
+	if p.StartingPoint == StartingPointCurrent {
+		return NewPath(StartingPointParent), nil
+	}
+
+	return nil, ErrNoParent
+}
+
+// Child returns a new path adding the specified segments.
+func (p *Path) Child(segments ...uint32) *Path {
+	child := NewPath(p.StartingPoint, p.Segments...)
+	child.Segments = append(child.Segments, segments...)
+
+	return child
+}
+
+// Resolve returns the absolute path obtained resolving p against current,
+// the same way the card does when deriving from the parent or the current key.
+// Absolute paths are returned unchanged.
+func (p *Path) Resolve(current *Path) (*Path, error) {
+	switch p.StartingPoint {
+	case StartingPointMaster:
+		return NewPath(StartingPointMaster, p.Segments...), nil
+	case StartingPointCurrent, StartingPointParent:
+		if current == nil || !current.IsAbsolute() {
+			return nil, ErrRelativeCurrentPath
+		}
+
+		base := current
+		if p.StartingPoint == StartingPointParent {
+			var err error
+			if base, err = current.Parent(); err != nil {
+				return nil, err
+			}
+		}
+
+		return base.Child(p.Segments...), nil
+	default:
+		return nil, errors.New("invalid starting point")
+	}
+}
+
+// Equal returns true if p and other have the same starting point and segments.
+func (p *Path) Equal(other *Path) bool {
+	if other == nil || p.StartingPoint != other.StartingPoint || len(p.Segments) != len(other.Segments) {
+		return false
+	}
+
+	for i := range p.Segments {
+		if p.Segments[i] != other.Segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (p Path) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (p *Path) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = *parsed
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package derivationpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPath_ParentAndChild(t *testing.T) {
+	p := MustParse("m/44'/60'/0'/0/1")
+	assert.True(t, p.IsAbsolute())
+	assert.False(t, p.IsHardened())
+
+	parent, err := p.Parent()
+	assert.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/0'/0", parent.String())
+
+	grandParent, err := parent.Parent()
+	assert.NoError(t, err)
+	assert.True(t, grandParent.IsHardened())
+
+	child := parent.Child(7)
+	assert.Equal(t, "m/44'/60'/0'/0/7", child.String())
+	// parent is not modified
+	assert.Equal(t, "m/44'/60'/0'/0", parent.String())
+
+	_, err = MustParse("m").Parent()
+	assert.Equal(t, ErrNoParent, err)
+
+	parent, err = MustParse(".").Parent()
+	assert.NoError(t, err)
+	assert.Equal(t, "..", parent.String())
+
+	_, err = MustParse("..").Parent()
+	assert.Equal(t, ErrNoParent, err)
+}
+
+func TestPath_Resolve(t *testing.T) {
+	current := MustParse("m/44'/60'/0'/0/1")
+
+	scenarios := []struct {
+		path     string
+		expected string
+		err      error
+	}{
+		{"m/1/2", "m/1/2", nil},
+		{".", "m/44'/60'/0'/0/1", nil},
+		{"3", "m/44'/60'/0'/0/1/3", nil},
+		{"./3/4'", "m/44'/60'/0'/0/1/3/4'", nil},
+		{"..", "m/44'/60'/0'/0", nil},
+		{"../5", "m/44'/60'/0'/0/5", nil},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("scenario %d", i), func(t *testing.T) {
+			resolved, err := MustParse(s.path).Resolve(current)
+			assert.NoError(t, err)
+			assert.Equal(t, s.expected, resolved.String())
+		})
+	}
+
+	_, err := MustParse("../1").Resolve(MustParse("m"))
+	assert.Equal(t, ErrNoParent, err)
+
+	_, err = MustParse("1").Resolve(MustParse("../1"))
+	assert.Equal(t, ErrRelativeCurrentPath, err)
+
+	_, err = MustParse("1").Resolve(nil)
+	assert.Equal(t, ErrRelativeCurrentPath, err)
+
+	resolved, err := MustParse("m/1").Resolve(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/1", resolved.String())
+}
+
+func TestPath_Marshalling(t *testing.T) {
+	type wrapper struct {
+		Abs *Path `json:"abs"`
+		Rel Path  `json:"rel"`
+	}
+
+	w := wrapper{
+		Abs: MustParse("m/44'/60'/0'/0/1"),
+		Rel: *MustParse("../2"),
+	}
+
+	data, err := json.Marshal(w)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"abs":"m/44'/60'/0'/0/1","rel":"../2"}`, string(data))
+
+	var decoded wrapper
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, w.Abs.Equal(decoded.Abs))
+	assert.True(t, w.Rel.Equal(&decoded.Rel))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"abs":"m/x"}`), &decoded))
+}
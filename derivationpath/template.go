@@ -0,0 +1,274 @@
+package derivationpath
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	tokenWildcard   = "*"
+	tokenRangeStart = "{"
+	tokenRangeEnd   = "}"
+	tokenRangeSep   = "-"
+	maxIndex        = hardenedStart - 1
+)
+
+var ErrTemplateNotAbsolute = errors.New("template must start with m")
+
+type templateSegment struct {
+	first    uint32
+	last     uint32
+	hardened bool
+}
+
+func (s templateSegment) contains(i uint32) bool {
+	if s.hardened != (i >= hardenedStart) {
+		return false
+	}
+
+	if s.hardened {
+		i -= hardenedStart
+	}
+
+	return i >= s.first && i <= s.last
+}
+
+func (s templateSegment) value(i uint32) uint32 {
+	if s.hardened {
+		return i + hardenedStart
+	}
+
+	return i
+}
+
+func (s templateSegment) String() string {
+	var str string
+	switch {
+	case s.first == 0 && s.last == maxIndex:
+		str = tokenWildcard
+	case s.first == s.last:
+		str = strconv.FormatUint(uint64(s.first), 10)
+	default:
+		str = fmt.Sprintf("%s%d%s%d%s", tokenRangeStart, s.first, tokenRangeSep, s.last, tokenRangeEnd)
+	}
+
+	if s.hardened {
+		str += string(rune(tokenHardened))
+	}
+
+	return str
+}
+
+// Template describes a set of absolute derivation paths.
+// Each segment can be an index ("0"), a range ("{0-19}") or a wildcard ("*"),
+// optionally followed by "'" to mark it as hardened.
+// Example: m/44'/60'/*'/0/{0-19}
+type Template struct {
+	segments []templateSegment
+}
+
+// ParseTemplate parses a template string like m/44'/60'/0'/0/{0-19}.
+func ParseTemplate(str string) (*Template, error) {
+	parts := strings.Split(str, string(rune(tokenSeparator)))
+	if parts[0] != string(rune(tokenMaster)) {
+		return nil, ErrTemplateNotAbsolute
+	}
+
+	t := &Template{
+		segments: make([]templateSegment, 0, len(parts)-1),
+	}
+
+	for i, part := range parts[1:] {
+		s, err := parseTemplateSegment(part)
+		if err != nil {
+			return nil, fmt.Errorf("at segment %d, %s", i+1, err.Error())
+		}
+
+		t.segments = append(t.segments, s)
+	}
+
+	return t, nil
+}
+
+// MustParseTemplate is like ParseTemplate but panics if str is not a valid template.
+func MustParseTemplate(str string) *Template {
+	t, err := ParseTemplate(str)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func parseTemplateSegment(str string) (templateSegment, error) {
+	s := templateSegment{}
+
+	if strings.HasSuffix(str, string(rune(tokenHardened))) {
+		s.hardened = true
+		str = str[:len(str)-1]
+	}
+
+	switch {
+	case str == "":
+		return s, errors.New("expected index, range or wildcard")
+	case str == tokenWildcard:
+		s.first = 0
+		s.last = maxIndex
+	case strings.HasPrefix(str, tokenRangeStart) && strings.HasSuffix(str, tokenRangeEnd):
+		bounds := strings.Split(str[1:len(str)-1], tokenRangeSep)
+		if len(bounds) != 2 {
+			return s, fmt.Errorf("invalid range %s", str)
+		}
+
+		first, err := parseTemplateIndex(bounds[0])
+		if err != nil {
+			return s, err
+		}
+
+		last, err := parseTemplateIndex(bounds[1])
+		if err != nil {
+			return s, err
+		}
+
+		if first > last {
+			return s, fmt.Errorf("invalid range %s, start is greater than end", str)
+		}
+
+		s.first = first
+		s.last = last
+	default:
+		i, err := parseTemplateIndex(str)
+		if err != nil {
+			return s, err
+		}
+
+		s.first = i
+		s.last = i
+	}
+
+	return s, nil
+}
+
+func parseTemplateIndex(str string) (uint32, error) {
+	i, err := strconv.ParseUint(str, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("expected number, got %s", str)
+	}
+
+	if i >= hardenedStart {
+		return 0, fmt.Errorf("index must be lower than 2^31, got %d", i)
+	}
+
+	return uint32(i), nil
+}
+
+// String returns the template string.
+func (t *Template) String() string {
+	segments := []string{string(rune(tokenMaster))}
+	for _, s := range t.segments {
+		segments = append(segments, s.String())
+	}
+
+	return strings.Join(segments, string(rune(tokenSeparator)))
+}
+
+// Match returns true if the absolute path is described by the template.
+func (t *Template) Match(path []uint32) bool {
+	if len(path) != len(t.segments) {
+		return false
+	}
+
+	for i, s := range t.segments {
+		if !s.contains(path[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchString decodes an absolute path and checks if it's described by the template.
+func (t *Template) MatchString(str string) (bool, error) {
+	startingPoint, path, err := Decode(str)
+	if err != nil {
+		return false, err
+	}
+
+	if startingPoint != StartingPointMaster {
+		return false, fmt.Errorf("path must be absolute, got %s", str)
+	}
+
+	return t.Match(path), nil
+}
+
+// Count returns the number of paths described by the template.
+// The result is capped to math.MaxUint64 for templates with many wildcards.
+func (t *Template) Count() uint64 {
+	count := uint64(1)
+	for _, s := range t.segments {
+		n := uint64(s.last-s.first) + 1
+		if count > math.MaxUint64/n {
+			return math.MaxUint64
+		}
+
+		count *= n
+	}
+
+	return count
+}
+
+// Iterator returns a new TemplateIterator that walks all the paths described by the template,
+// varying the last segment first.
+func (t *Template) Iterator() *TemplateIterator {
+	return &TemplateIterator{t: t}
+}
+
+// TemplateIterator iterates over the paths described by a Template.
+type TemplateIterator struct {
+	t       *Template
+	indexes []uint32
+	done    bool
+}
+
+// Next moves the iterator to the next path. It returns false when there are no more paths.
+func (it *TemplateIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	if it.indexes == nil {
+		it.indexes = make([]uint32, len(it.t.segments))
+		for i, s := range it.t.segments {
+			it.indexes[i] = s.first
+		}
+
+		return true
+	}
+
+	for i := len(it.indexes) - 1; i >= 0; i-- {
+		s := it.t.segments[i]
+		if it.indexes[i] < s.last {
+			it.indexes[i]++
+			return true
+		}
+
+		it.indexes[i] = s.first
+	}
+
+	it.done = true
+
+	return false
+}
+
+// Path returns the current path.
+func (it *TemplateIterator) Path() []uint32 {
+	path := make([]uint32, len(it.indexes))
+	for i, s := range it.t.segments {
+		path[i] = s.value(it.indexes[i])
+	}
+
+	return path
+}
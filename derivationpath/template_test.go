@@ -0,0 +1,143 @@
+package derivationpath
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTemplate(t *testing.T) {
+	scenarios := []struct {
+		template string
+		expected string
+		count    uint64
+		err      error
+	}{
+		{
+			template: "m",
+			expected: "m",
+			count:    1,
+		},
+		{
+			template: "m/44'/60'/0'/0/{0-19}",
+			expected: "m/44'/60'/0'/0/{0-19}",
+			count:    20,
+		},
+		{
+			template: "m/44'/60'/*'/0/0",
+			expected: "m/44'/60'/*'/0/0",
+			count:    hardenedStart,
+		},
+		{
+			template: "m/44'/60'/{0-1}'/{0-1}/{3-3}",
+			expected: "m/44'/60'/{0-1}'/{0-1}/3",
+			count:    4,
+		},
+		{
+			template: "m/*/*/*",
+			expected: "m/*/*/*",
+			count:    math.MaxUint64,
+		},
+		{
+			template: "44'/60'",
+			err:      ErrTemplateNotAbsolute,
+		},
+		{
+			template: "m/44'/",
+			err:      fmt.Errorf("at segment 2, expected index, range or wildcard"),
+		},
+		{
+			template: "m/{5-1}",
+			err:      fmt.Errorf("at segment 1, invalid range {5-1}, start is greater than end"),
+		},
+		{
+			template: "m/{1-2-3}",
+			err:      fmt.Errorf("at segment 1, invalid range {1-2-3}"),
+		},
+		{
+			template: "m/a",
+			err:      fmt.Errorf("at segment 1, expected number, got a"),
+		},
+		{
+			template: "m/{0-2147483648}",
+			err:      fmt.Errorf("at segment 1, index must be lower than 2^31, got 2147483648"),
+		},
+	}
+
+	for i, s := range scenarios {
+		t.Run(fmt.Sprintf("scenario %d", i), func(t *testing.T) {
+			tpl, err := ParseTemplate(s.template)
+			if s.err != nil {
+				assert.Equal(t, s.err, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, s.expected, tpl.String())
+			assert.Equal(t, s.count, tpl.Count())
+		})
+	}
+}
+
+func TestTemplate_Match(t *testing.T) {
+	tpl := MustParseTemplate("m/44'/60'/*'/0/{0-19}")
+
+	scenarios := []struct {
+		path    string
+		matches bool
+	}{
+		{"m/44'/60'/0'/0/0", true},
+		{"m/44'/60'/7'/0/19", true},
+		{"m/44'/60'/7'/0/20", false},
+		{"m/44'/60'/7/0/1", false},
+		{"m/44'/60'/7'/0'/1", false},
+		{"m/44'/60'/7'/1/1", false},
+		{"m/44'/60'/7'/0", false},
+		{"m/44'/60'/7'/0/1/2", false},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.path, func(t *testing.T) {
+			matches, err := tpl.MatchString(s.path)
+			assert.NoError(t, err)
+			assert.Equal(t, s.matches, matches)
+		})
+	}
+
+	_, err := tpl.MatchString("../1")
+	assert.Error(t, err)
+}
+
+func TestTemplate_Iterator(t *testing.T) {
+	tpl := MustParseTemplate("m/44'/{0-1}'/{5-6}")
+
+	paths := make([]string, 0)
+	it := tpl.Iterator()
+	for it.Next() {
+		assert.True(t, tpl.Match(it.Path()))
+		paths = append(paths, Encode(it.Path()))
+	}
+
+	expected := []string{
+		"m/44'/0'/5",
+		"m/44'/0'/6",
+		"m/44'/1'/5",
+		"m/44'/1'/6",
+	}
+
+	assert.Equal(t, expected, paths)
+	assert.False(t, it.Next())
+}
+
+func TestTemplate_IteratorWildcard(t *testing.T) {
+	tpl := MustParseTemplate("m/44'/60'/*'/0/0")
+
+	it := tpl.Iterator()
+	for i := 0; i < 3; i++ {
+		assert.True(t, it.Next())
+	}
+
+	assert.Equal(t, "m/44'/60'/2'/0/0", Encode(it.Path()))
+}
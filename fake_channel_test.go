@@ -0,0 +1,63 @@
+package keycard
+
+import (
+	"github.com/status-im/keycard-go/apdu"
+)
+
+type commandHandler func(cmd *apdu.Command) *apdu.Response
+
+// scriptedChannel is a fake types.Channel answering each command with the handler
+// registered for its INS. All the commands sent are recorded.
+type scriptedChannel struct {
+	handlers map[uint8]commandHandler
+	// fallback answers the commands without a handler. If nil, they get 6D00.
+	fallback commandHandler
+	commands []*apdu.Command
+}
+
+func newScriptedChannel() *scriptedChannel {
+	return &scriptedChannel{handlers: make(map[uint8]commandHandler)}
+}
+
+// on sets the handler of the commands with the specified INS.
+func (c *scriptedChannel) on(ins uint8, handler commandHandler) *scriptedChannel {
+	c.handlers[ins] = handler
+	return c
+}
+
+// respond answers all the commands with the specified INS with the same response.
+func (c *scriptedChannel) respond(ins uint8, data []byte, sw uint16) *scriptedChannel {
+	return c.on(ins, func(*apdu.Command) *apdu.Response {
+		return response(data, sw)
+	})
+}
+
+// sent returns the recorded commands with the specified INS.
+func (c *scriptedChannel) sent(ins uint8) []*apdu.Command {
+	commands := make([]*apdu.Command, 0)
+	for _, cmd := range c.commands {
+		if cmd.Ins == ins {
+			commands = append(commands, cmd)
+		}
+	}
+
+	return commands
+}
+
+func (c *scriptedChannel) Send(cmd *apdu.Command) (*apdu.Response, error) {
+	c.commands = append(c.commands, cmd)
+
+	if handler, ok := c.handlers[cmd.Ins]; ok {
+		return handler(cmd), nil
+	}
+
+	if c.fallback != nil {
+		return c.fallback(cmd), nil
+	}
+
+	return response(nil, 0x6D00), nil
+}
+
+func response(data []byte, sw uint16) *apdu.Response {
+	return &apdu.Response{Data: data, Sw1: uint8(sw >> 8), Sw2: uint8(sw), Sw: sw}
+}
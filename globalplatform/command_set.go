@@ -1,16 +1,41 @@
 package globalplatform
 
 import (
+	"bytes"
 	"crypto/rand"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"sync/atomic"
 
 	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/capfile"
+	"github.com/status-im/keycard-go/globalplatform/crypto"
 	"github.com/status-im/keycard-go/identifiers"
 	"github.com/status-im/keycard-go/types"
 )
 
-var ErrSecureChannelNotOpen = errors.New("secure channel not open")
+// Confirmations of the irreversible status changes.
+const (
+	ConfirmTerminateCard          = "terminate card"
+	ConfirmPersonalizeApplication = "personalize application"
+)
+
+var (
+	ErrSecureChannelNotOpen = errors.New("secure channel not open")
+	ErrPackageAIDMismatch   = errors.New("package AID doesn't match the CAP file")
+	ErrNotConfirmed         = errors.New("irreversible status change not confirmed")
+)
+
+// KeyVersionNotFoundError is returned when the card doesn't have the key version requested with SetKeyVersion.
+type KeyVersionNotFoundError struct {
+	Version uint8
+}
+
+func (e *KeyVersionNotFoundError) Error() string {
+	return fmt.Sprintf("key version %d not found on the card", e.Version)
+}
 
 type LoadingCallback = func(loadingBlock, totalBlocks int)
 
@@ -18,14 +43,55 @@ type CommandSet struct {
 	c       types.Channel
 	sc      *SecureChannel
 	session *Session
+	keySets []*KeySet
+	keySet  *KeySet
+	level   uint8
+	hash    LoadFileHash
+	// keyVersion is the key version requested with INITIALIZE UPDATE, 0 for the card default.
+	keyVersion uint8
+	// invalidated is set by Invalidate, possibly from another goroutine. The secure channel is closed
+	// before the next command.
+	invalidated int32
 }
 
 func NewCommandSet(c types.Channel) *CommandSet {
 	return &CommandSet{
-		c: c,
+		c:       c,
+		keySets: DefaultKeySets(),
+		hash:    LoadFileHashSHA1,
 	}
 }
 
+// SetLoadFileHash sets the algorithm used to hash the load file data block when loading packages.
+// The default is SHA-1, supported by most cards.
+func (cs *CommandSet) SetLoadFileHash(h LoadFileHash) {
+	cs.hash = h
+}
+
+// SetKeyVersion sets the key version used to open the secure channel, useful when the card has several key versions.
+// Version 0 lets the card choose.
+func (cs *CommandSet) SetKeyVersion(version uint8) {
+	cs.keyVersion = version
+}
+
+// Session returns the current secure channel session, nil if the secure channel is not open.
+func (cs *CommandSet) Session() *Session {
+	cs.resetIfInvalidated()
+	return cs.session
+}
+
+// SetKeySets sets the key sets tried, in order, when opening a secure channel.
+func (cs *CommandSet) SetKeySets(keySets ...*KeySet) {
+	cs.keySets = keySets
+}
+
+// KeySet returns the card keys used to open the current secure channel, after diversification if needed,
+// with the key version returned by the card. It returns nil if the secure channel is not open.
+func (cs *CommandSet) KeySet() *KeySet {
+	cs.resetIfInvalidated()
+	return cs.keySet
+}
+
 func (cs *CommandSet) Select() error {
 	return cs.SelectAID(nil)
 }
@@ -38,7 +104,14 @@ func (cs *CommandSet) SelectAID(aid []byte) error {
 	return cs.checkOK(resp, err)
 }
 
+// OpenSecureChannel opens an SCP02 or SCP03 secure channel, depending on the version
+// returned by the card, with the C-MAC security level.
 func (cs *CommandSet) OpenSecureChannel() error {
+	return cs.OpenSecureChannelWithSecurityLevel(SecurityLevelCMAC)
+}
+
+// OpenSecureChannelWithSecurityLevel opens a secure channel with the specified security level.
+func (cs *CommandSet) OpenSecureChannelWithSecurityLevel(securityLevel uint8) error {
 	hostChallenge, err := generateHostChallenge()
 	if err != nil {
 		return err
@@ -49,14 +122,118 @@ func (cs *CommandSet) OpenSecureChannel() error {
 		return err
 	}
 
-	return cs.externalAuthenticate()
+	if err = cs.externalAuthenticate(securityLevel); err != nil {
+		return err
+	}
+
+	cs.level = securityLevel
+
+	return nil
+}
+
+// Invalidate closes the secure channel without contacting the card. It must be called when the card is removed.
+// It can be called from another goroutine, like a monitor callback: the secure channel is closed by the goroutine
+// using the CommandSet, before sending the next command.
+func (cs *CommandSet) Invalidate() {
+	atomic.StoreInt32(&cs.invalidated, 1)
+}
+
+// resetIfInvalidated closes the secure channel if Invalidate has been called since the last reset.
+func (cs *CommandSet) resetIfInvalidated() {
+	if atomic.CompareAndSwapInt32(&cs.invalidated, 1, 0) {
+		cs.closeSecureChannel()
+	}
+}
+
+func (cs *CommandSet) closeSecureChannel() {
+	cs.sc = nil
+	cs.session = nil
+	cs.keySet = nil
+	cs.level = 0
 }
 
-func (cs *CommandSet) DeleteKeycardInstancesAndPackage() error {
+// checkSecureChannel returns ErrSecureChannelNotOpen if the secure channel is not open or has been invalidated.
+func (cs *CommandSet) checkSecureChannel() error {
+	cs.resetIfInvalidated()
+
 	if cs.sc == nil {
 		return ErrSecureChannelNotOpen
 	}
 
+	return nil
+}
+
+// PutKeys loads the keys of keySet on the card using keySet.Version as the new key version.
+// If replaceVersion is 0 the keys are added, otherwise the keys with version replaceVersion are replaced.
+// If keySet uses diversification, the diversified keys are loaded.
+func (cs *CommandSet) PutKeys(keySet *KeySet, replaceVersion uint8) error {
+	if err := cs.checkSecureChannel(); err != nil {
+		return err
+	}
+
+	keys, err := keySet.Diversify(cs.session.divData)
+	if err != nil {
+		return err
+	}
+
+	dek, err := cs.dataEncryptionKey()
+	if err != nil {
+		return err
+	}
+
+	data, expected, err := keys.putKeyData(cs.session.SCPVersion(), dek)
+	if err != nil {
+		return err
+	}
+
+	cmd := NewCommandPutKey(replaceVersion, P2PutKeyMultipleKeys|keyTypeEnc, data)
+	resp, err := cs.sc.Send(cmd)
+	if err = cs.checkOK(resp, err); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(resp.Data, expected) {
+		return ErrBadKeyCheckValue
+	}
+
+	return nil
+}
+
+// RotateKeys replaces the keys used to open the current secure channel with newKeySet,
+// then checks the new keys opening a new secure channel with them.
+// After that, newKeySet is the only key set used by the CommandSet.
+func (cs *CommandSet) RotateKeys(newKeySet *KeySet) error {
+	if err := cs.checkSecureChannel(); err != nil {
+		return err
+	}
+
+	if err := cs.PutKeys(newKeySet, cs.keySet.Version); err != nil {
+		return err
+	}
+
+	cs.SetKeySets(newKeySet)
+	cs.SetKeyVersion(newKeySet.Version)
+
+	return cs.OpenSecureChannelWithSecurityLevel(cs.level)
+}
+
+// dataEncryptionKey returns the key used to encrypt sensitive data like keys.
+// SCP02 uses a session key derived from the static DEK, SCP03 uses the static DEK.
+func (cs *CommandSet) dataEncryptionKey() ([]byte, error) {
+	if cs.session.SCPVersion() == SCP03 {
+		return cs.keySet.Dek, nil
+	}
+
+	seq := cs.session.CardChallenge()[0:2]
+
+	return crypto.DeriveKey(cs.keySet.Dek, seq, crypto.DerivationPurposeDek)
+}
+
+func (cs *CommandSet) DeleteKeycardInstancesAndPackage() error {
+	if err := cs.checkSecureChannel(); err != nil {
+		return err
+	}
+
 	return cs.DeleteObjectAndRelatedObject(identifiers.PackageAID)
 }
 
@@ -69,6 +246,10 @@ func (cs *CommandSet) DeleteObjectAndRelatedObject(aid []byte) error {
 }
 
 func (cs *CommandSet) Delete(aid []byte, p2 uint8) error {
+	if err := cs.checkSecureChannel(); err != nil {
+		return err
+	}
+
 	cmd := NewCommandDelete(aid, p2)
 	resp, err := cs.sc.Send(cmd)
 	return cs.checkOK(resp, err, SwOK, SwReferencedDataNotFound)
@@ -78,32 +259,57 @@ func (cs *CommandSet) LoadKeycardPackage(capFile *os.File, callback LoadingCallb
 	return cs.LoadPackage(capFile, identifiers.PackageAID, callback)
 }
 
+// LoadPackage loads the package in capFile after checking that its AID is pkgAID.
 func (cs *CommandSet) LoadPackage(capFile *os.File, pkgAID []byte, callback LoadingCallback) error {
-	if cs.sc == nil {
-		return ErrSecureChannelNotOpen
+	fi, err := capFile.Stat()
+	if err != nil {
+		return err
 	}
 
-	preLoad := NewCommandInstallForLoad(pkgAID, []byte{})
-	resp, err := cs.sc.Send(preLoad)
-	if err = cs.checkOK(resp, err); err != nil {
+	return cs.LoadPackageFromReader(capFile, fi.Size(), pkgAID, callback)
+}
+
+// LoadPackageFromReader loads the package of the CAP file of the specified size read from r,
+// after checking that its AID is pkgAID. The CAP file is streamed to the card one block at a time.
+// callback, if not nil, is called before sending each block.
+func (cs *CommandSet) LoadPackageFromReader(r io.ReaderAt, size int64, pkgAID []byte, callback LoadingCallback) error {
+	if err := cs.checkSecureChannel(); err != nil {
+		return err
+	}
+
+	c, err := capfile.Parse(r, size)
+	if err != nil {
 		return err
 	}
 
-	load, err := NewLoadCommandStream(capFile)
+	if !bytes.Equal(c.PackageAID(), pkgAID) {
+		return ErrPackageAIDMismatch
+	}
+
+	hash, err := cs.hash.Compute(c)
 	if err != nil {
 		return err
 	}
 
+	preLoad := NewCommandInstallForLoadWithHash(pkgAID, []byte{}, hash)
+	resp, err := cs.sc.Send(preLoad)
+	if err = cs.checkOK(resp, err); err != nil {
+		return err
+	}
+
+	load := NewLoadCommandStreamFromCapFile(c, cs.sc.MaxDataLength())
 	for load.Next() {
 		cmd := load.GetCommand()
-		callback(int(load.Index()), load.BlocksCount())
+		if callback != nil {
+			callback(int(load.Index()), load.BlocksCount())
+		}
 		resp, err = cs.sc.Send(cmd)
 		if err = cs.checkOK(resp, err); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return load.Err()
 }
 
 func (cs *CommandSet) InstallNDEFApplet(ndefRecord []byte) error {
@@ -115,16 +321,49 @@ func (cs *CommandSet) InstallNDEFApplet(ndefRecord []byte) error {
 }
 
 func (cs *CommandSet) InstallKeycardApplet() error {
-	instanceAID, err := identifiers.KeycardInstanceAID(identifiers.KeycardDefaultInstanceIndex)
+	return cs.InstallKeycardInstance(&KeycardInstallParams{InstanceIndex: identifiers.KeycardDefaultInstanceIndex})
+}
+
+// InstallKeycardInstance installs the Keycard instance described by params.
+// Other instances on the card are not affected.
+func (cs *CommandSet) InstallKeycardInstance(params *KeycardInstallParams) error {
+	instanceAID, err := params.InstanceAID()
 	if err != nil {
 		return err
 	}
 
-	return cs.InstallForInstall(
+	return cs.InstallForInstallWithParams(
 		identifiers.PackageAID,
 		identifiers.KeycardAID,
 		instanceAID,
-		[]byte{})
+		params.InstallParams())
+}
+
+// DeleteKeycardInstance deletes the Keycard instance with the specified index, together with its keys.
+func (cs *CommandSet) DeleteKeycardInstance(index int) error {
+	instanceAID, err := identifiers.KeycardInstanceAID(index)
+	if err != nil {
+		return err
+	}
+
+	return cs.DeleteObject(instanceAID)
+}
+
+// ListKeycardInstances returns the indexes of the Keycard instances installed on the card.
+func (cs *CommandSet) ListKeycardInstances() ([]int, error) {
+	apps, err := cs.GetStatusApplications()
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, 0)
+	for _, app := range apps {
+		if index, err := identifiers.KeycardInstanceIndex(app.AID); err == nil {
+			indexes = append(indexes, index)
+		}
+	}
+
+	return indexes, nil
 }
 
 func (cs *CommandSet) InstallCashApplet() error {
@@ -135,13 +374,49 @@ func (cs *CommandSet) InstallCashApplet() error {
 		[]byte{})
 }
 
+// InstallCashAppletWithPublicData installs the Cash applet with the specified public data.
+func (cs *CommandSet) InstallCashAppletWithPublicData(publicData *types.CashPublicData) error {
+	data, err := publicData.Encode()
+	if err != nil {
+		return err
+	}
+
+	return cs.InstallForInstall(
+		identifiers.PackageAID,
+		identifiers.CashAID,
+		identifiers.CashInstanceAID,
+		data)
+}
+
 func (cs *CommandSet) InstallForInstall(packageAID, appletAID, instanceAID, params []byte) error {
-	cmd := NewCommandInstallForInstall(packageAID, appletAID, instanceAID, params)
+	return cs.InstallForInstallWithParams(packageAID, appletAID, instanceAID, NewInstallParams(params))
+}
+
+// InstallForInstallWithParams installs and makes selectable an instance with the specified privileges and parameters.
+// Nil params install the instance without parameters.
+func (cs *CommandSet) InstallForInstallWithParams(packageAID, appletAID, instanceAID []byte, params *InstallParams) error {
+	if err := cs.checkSecureChannel(); err != nil {
+		return err
+	}
+
+	if params == nil {
+		params = NewInstallParams(nil)
+	}
+
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	cmd := NewCommandInstallForInstallWithParams(packageAID, appletAID, instanceAID, params)
 	resp, err := cs.sc.Send(cmd)
 	return cs.checkOK(resp, err)
 }
 
 func (cs *CommandSet) GetStatus() (*types.CardStatus, error) {
+	if err := cs.checkSecureChannel(); err != nil {
+		return nil, err
+	}
+
 	cmd := NewCommandGetStatus([]byte{}, P1GetStatusIssuerSecurityDomain)
 	resp, err := cs.sc.Send(cmd)
 	if err = cs.checkOK(resp, err); err != nil {
@@ -151,21 +426,196 @@ func (cs *CommandSet) GetStatus() (*types.CardStatus, error) {
 	return types.ParseCardStatus(resp.Data)
 }
 
+// GetCPLC returns the Card Production Life Cycle data.
+func (cs *CommandSet) GetCPLC() (*types.CPLC, error) {
+	data, err := cs.getData(P1P2GetDataCPLC)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.ParseCPLC(data)
+}
+
+// GetCardRecognitionData returns the globalplatform version and the secure channel protocols supported by the card.
+func (cs *CommandSet) GetCardRecognitionData() (*types.CardRecognitionData, error) {
+	data, err := cs.getData(P1P2GetDataCardData)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.ParseCardRecognitionData(data)
+}
+
+// GetKeyInformation returns the keys of the selected security domain.
+func (cs *CommandSet) GetKeyInformation() ([]*types.KeyInfo, error) {
+	data, err := cs.getData(P1P2GetDataKeyInformation)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.ParseKeyInformation(data)
+}
+
+// getData sends GET DATA through the secure channel if open, since some cards require it, or in plain otherwise.
+func (cs *CommandSet) getData(p1p2 uint16) ([]byte, error) {
+	cs.resetIfInvalidated()
+	cmd := NewCommandGetData(p1p2)
+
+	var (
+		resp *apdu.Response
+		err  error
+	)
+
+	if cs.sc != nil {
+		resp, err = cs.sc.Send(cmd)
+	} else {
+		resp, err = cs.c.Send(cmd)
+	}
+
+	if err = cs.checkOK(resp, err); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// LockCard moves the card to the CARD_LOCKED state. Only the issuer security domain can be selected
+// until the card is unlocked.
+func (cs *CommandSet) LockCard() error {
+	return cs.setStatus(P1SetStatusIssuerSecurityDomain, P2SetStatusCardLocked, []byte{})
+}
+
+// UnlockCard moves a locked card back to the SECURED state.
+func (cs *CommandSet) UnlockCard() error {
+	return cs.setStatus(P1SetStatusIssuerSecurityDomain, P2SetStatusCardSecured, []byte{})
+}
+
+// TerminateCard moves the card to the TERMINATED state. The card can't be used anymore after that,
+// so confirmation must be ConfirmTerminateCard.
+func (cs *CommandSet) TerminateCard(confirmation string) error {
+	if confirmation != ConfirmTerminateCard {
+		return ErrNotConfirmed
+	}
+
+	return cs.setStatus(P1SetStatusIssuerSecurityDomain, P2SetStatusCardTerminated, []byte{})
+}
+
+// LockApplication locks the application with the specified AID, making it not selectable.
+func (cs *CommandSet) LockApplication(aid []byte) error {
+	return cs.setStatus(P1SetStatusApplication, P2SetStatusApplicationLocked, aid)
+}
+
+// UnlockApplication unlocks the application with the specified AID.
+func (cs *CommandSet) UnlockApplication(aid []byte) error {
+	return cs.setStatus(P1SetStatusApplication, P2SetStatusApplicationUnlocked, aid)
+}
+
+// PersonalizeApplication moves the application with the specified AID to the PERSONALIZED state,
+// which can't be reverted, so confirmation must be ConfirmPersonalizeApplication.
+// Cards may allow only the application itself to change its application specific state.
+func (cs *CommandSet) PersonalizeApplication(aid []byte, confirmation string) error {
+	if confirmation != ConfirmPersonalizeApplication {
+		return ErrNotConfirmed
+	}
+
+	return cs.setStatus(P1SetStatusApplication, P2SetStatusPersonalized, aid)
+}
+
+func (cs *CommandSet) setStatus(p1, p2 uint8, aid []byte) error {
+	if err := cs.checkSecureChannel(); err != nil {
+		return err
+	}
+
+	cmd := NewCommandSetStatus(p1, p2, aid)
+	resp, err := cs.sc.Send(cmd)
+	return cs.checkOK(resp, err)
+}
+
+// GetStatusApplications returns the applications and security domains installed on the card.
+func (cs *CommandSet) GetStatusApplications() ([]*types.ApplicationEntry, error) {
+	data, err := cs.getStatusAll(P1GetStatusApplications)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.ParseApplicationEntries(data)
+}
+
+// GetStatusLoadFiles returns the executable load files on the card.
+func (cs *CommandSet) GetStatusLoadFiles() ([]*types.LoadFileEntry, error) {
+	data, err := cs.getStatusAll(P1GetStatusExecLoadFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.ParseLoadFileEntries(data)
+}
+
+// GetStatusLoadFilesAndModules returns the executable load files on the card with their executable modules.
+func (cs *CommandSet) GetStatusLoadFilesAndModules() ([]*types.LoadFileEntry, error) {
+	data, err := cs.getStatusAll(P1GetStatusExecLoadFilesAndModules)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.ParseLoadFileEntries(data)
+}
+
+// getStatusAll sends GET STATUS until the card has returned all the entries, and returns the concatenated responses.
+// No data is returned if the card has no entries.
+func (cs *CommandSet) getStatusAll(p1 uint8) ([]byte, error) {
+	if err := cs.checkSecureChannel(); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0)
+	cmd := NewCommandGetStatus([]byte{}, p1)
+
+	for {
+		resp, err := cs.sc.Send(cmd)
+		if err = cs.checkOK(resp, err, SwOK, SwMoreData, SwReferencedDataNotFound); err != nil {
+			return nil, err
+		}
+
+		data = append(data, resp.Data...)
+
+		if resp.Sw != SwMoreData {
+			return data, nil
+		}
+
+		cmd = NewCommandGetStatus([]byte{}, p1)
+		cmd.P2 |= P2GetStatusNext
+	}
+}
+
 func (cs *CommandSet) Channel() types.Channel {
 	return cs.c
 }
 
 func (cs *CommandSet) SecureChannel() *SecureChannel {
+	cs.resetIfInvalidated()
 	return cs.sc
 }
 
 func (cs *CommandSet) initializeUpdate(hostChallenge []byte) error {
-	cmd := NewCommandInitializeUpdate(hostChallenge)
+	atomic.StoreInt32(&cs.invalidated, 0)
+	cs.closeSecureChannel()
+
+	cmd := NewCommandInitializeUpdateWithKeyVersion(hostChallenge, cs.keyVersion)
 	resp, err := cs.c.Send(cmd)
+	if err == nil && resp.Sw == SwReferencedDataNotFound && cs.keyVersion != 0 {
+		return &KeyVersionNotFoundError{cs.keyVersion}
+	}
+
 	if err = cs.checkOK(resp, err); err != nil {
 		return err
 	}
 
+	// cards should reject unknown key versions, but some fall back to the default keys
+	if cs.keyVersion != 0 && len(resp.Data) > 10 && resp.Data[10] != cs.keyVersion {
+		return &KeyVersionNotFoundError{cs.keyVersion}
+	}
+
 	// verify cryptogram and initialize session keys
 	session, err := cs.initializeSession(resp, hostChallenge)
 	if err != nil {
@@ -178,23 +628,44 @@ func (cs *CommandSet) initializeUpdate(hostChallenge []byte) error {
 	return nil
 }
 
-func (cs *CommandSet) initializeSession(resp *apdu.Response, hostChallenge []byte) (session *Session, err error) {
-	keySets := []struct {
-		name string
-		key  []byte
-	}{
-		{"keycard", identifiers.KeycardDevelopmentKey},
-		{"globalplatform", identifiers.GlobalPlatformDefaultKey},
+func (cs *CommandSet) initializeSession(resp *apdu.Response, hostChallenge []byte) (*Session, error) {
+	scpVersion, err := SCPVersion(resp)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, set := range keySets {
-		logger.Debug("initialize session", "keys", set.name)
-		keys := NewSCP02Keys(set.key, set.key)
-		session, err = NewSession(keys, resp, hostChallenge)
+	divData := resp.Data[0:10]
+	keyVersion := resp.Data[10]
+
+	for i, ks := range cs.keySets {
+		if !ks.matchesVersion(keyVersion) {
+			continue
+		}
+
+		logger.Debug("initialize session", "keys", i, "version", keyVersion, "scp", scpVersion)
+		keys, err := ks.Diversify(divData)
+		if err != nil {
+			logger.Warn("initialize session", "keys", i, "diversification error", err)
+			continue
+		}
+
+		var session *Session
+		if scpVersion == SCP03 {
+			session, err = NewSCP03Session(NewSCP03Keys(keys.Enc, keys.Mac), resp, hostChallenge)
+		} else {
+			session, err = NewSession(NewSCP02Keys(keys.Enc, keys.Mac), resp, hostChallenge)
+		}
 
 		// good keys
 		if err == nil {
-			break
+			cs.keySet = &KeySet{
+				Version: keyVersion,
+				Enc:     keys.Enc,
+				Mac:     keys.Mac,
+				Dek:     keys.Dek,
+			}
+
+			return session, nil
 		}
 
 		// try the next keys
@@ -206,22 +677,30 @@ func (cs *CommandSet) initializeSession(resp *apdu.Response, hostChallenge []byt
 		return nil, err
 	}
 
-	return session, err
+	return nil, ErrNoValidKeySet
 }
 
-func (cs *CommandSet) externalAuthenticate() error {
+func (cs *CommandSet) externalAuthenticate(securityLevel uint8) error {
 	if cs.session == nil {
 		return errors.New("session must be initialized using initializeUpdate")
 	}
 
-	encKey := cs.session.Keys().Enc()
-	cmd, err := NewCommandExternalAuthenticate(encKey, cs.session.CardChallenge(), cs.session.HostChallenge())
+	if !isSecurityLevelSupported(cs.session.SCPVersion(), securityLevel) {
+		return ErrUnsupportedSecurityLevel
+	}
+
+	hostCryptogram, err := cs.session.HostCryptogram()
 	if err != nil {
 		return err
 	}
 
+	cmd := NewCommandExternalAuthenticateWithCryptogram(securityLevel, hostCryptogram)
 	resp, err := cs.sc.Send(cmd)
-	return cs.checkOK(resp, err)
+	if err = cs.checkOK(resp, err); err != nil {
+		return err
+	}
+
+	return cs.sc.SetSecurityLevel(securityLevel)
 }
 
 func (cs *CommandSet) checkOK(resp *apdu.Response, err error, allowedResponses ...uint16) error {
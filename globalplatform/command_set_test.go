@@ -0,0 +1,517 @@
+package globalplatform
+
+import (
+	"testing"
+
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/globalplatform/crypto"
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/status-im/keycard-go/identifiers"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCard answers INITIALIZE UPDATE as a SCP02 card with the specified static keys,
+// and records all the other commands. Queued responses are returned first.
+type fakeCard struct {
+	keys       *KeySet
+	nextKeys   *KeySet
+	keyVersion uint8
+	divData    []byte
+	responses  map[uint8][]*apdu.Response
+	commands   []*apdu.Command
+}
+
+func newFakeCard(keys *KeySet) *fakeCard {
+	return &fakeCard{
+		keys:       keys,
+		keyVersion: 0x01,
+		divData:    hexutils.HexToBytes("00010203040506070809"),
+		responses:  make(map[uint8][]*apdu.Response),
+	}
+}
+
+func (fc *fakeCard) Send(cmd *apdu.Command) (*apdu.Response, error) {
+	fc.commands = append(fc.commands, cmd)
+
+	if queue := fc.responses[cmd.Ins]; len(queue) > 0 {
+		fc.responses[cmd.Ins] = queue[1:]
+		return queue[0], nil
+	}
+
+	if cmd.Ins == InsInitializeUpdate {
+		if cmd.P1 != 0 && cmd.P1 != fc.keyVersion {
+			return &apdu.Response{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound}, nil
+		}
+
+		return fc.initializeUpdate(cmd.Data)
+	}
+
+	if cmd.Ins == InsPutKey && fc.nextKeys != nil {
+		return fc.putKey(cmd.Data)
+	}
+
+	return &apdu.Response{Sw1: 0x90, Sw2: 0x00, Sw: SwOK}, nil
+}
+
+func (fc *fakeCard) initializeUpdate(hostChallenge []byte) (*apdu.Response, error) {
+	seq := []byte{0x00, 0x65}
+	cardChallenge := append(append([]byte{}, seq...), 0x01, 0x02, 0x03, 0x04, 0x05, 0x06)
+
+	encKey, err := crypto.DeriveKey(fc.keys.Enc, seq, crypto.DerivationPurposeEnc)
+	if err != nil {
+		return nil, err
+	}
+
+	data := append(append([]byte{}, hostChallenge...), cardChallenge...)
+	cryptogram, err := crypto.Mac3DES(encKey, crypto.AppendDESPadding(data), crypto.NullBytes8)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := append([]byte{}, fc.divData...)
+	resp = append(resp, fc.keyVersion, SCP02)
+	resp = append(resp, cardChallenge...)
+	resp = append(resp, cryptogram...)
+
+	return &apdu.Response{Data: resp, Sw1: 0x90, Sw2: 0x00, Sw: SwOK}, nil
+}
+
+// putKey accepts the keys of a PUT KEY command with 3 DES keys, returning their KCVs.
+// The keys are replaced by nextKeys.
+func (fc *fakeCard) putKey(data []byte) (*apdu.Response, error) {
+	resp := []byte{data[0]}
+	for i := 0; i < 3; i++ {
+		kcv := data[1+i*22+19 : 1+i*22+22]
+		resp = append(resp, kcv...)
+	}
+
+	fc.keys = fc.nextKeys
+	fc.keyVersion = data[0]
+	fc.nextKeys = nil
+
+	return &apdu.Response{Data: resp, Sw1: 0x90, Sw2: 0x00, Sw: SwOK}, nil
+}
+
+// fakeSCP03Card is a SCP03 card with the specified static keys. It verifies the host cryptogram and
+// the MAC of each command, decrypts the command data and MACs and encrypts the responses depending on
+// the security level. The other commands are answered with the queued responses, or with 9000.
+// The session keys and the cryptograms are derived with scp03KDF, not with crypto.DeriveKeySCP03.
+type fakeSCP03Card struct {
+	t             *testing.T
+	keys          *KeySet
+	cardChallenge []byte
+	context       []byte
+	enc, mac      []byte
+	rmac          []byte
+	level         uint8
+	chainingValue []byte
+	counter       uint32
+	responses     []*apdu.Response
+	commands      []*apdu.Command
+}
+
+func newFakeSCP03Card(t *testing.T, keys *KeySet) *fakeSCP03Card {
+	return &fakeSCP03Card{
+		t:             t,
+		keys:          keys,
+		cardChallenge: hexutils.HexToBytes("1011121314151617"),
+	}
+}
+
+// scp03KDF builds the derivation data of GP Amendment D 4.1.5 and computes the CMAC of each block.
+func scp03KDF(key []byte, constant byte, context []byte, bits int) []byte {
+	out := make([]byte, 0)
+	for i := byte(1); len(out) < bits/8; i++ {
+		data := make([]byte, 11)
+		data = append(data, constant, 0x00, byte(bits>>8), byte(bits), i)
+		data = append(data, context...)
+
+		mac, err := crypto.CMAC(key, data)
+		if err != nil {
+			panic(err)
+		}
+
+		out = append(out, mac...)
+	}
+
+	return out[:bits/8]
+}
+
+func (fc *fakeSCP03Card) Send(cmd *apdu.Command) (*apdu.Response, error) {
+	fc.commands = append(fc.commands, cmd)
+
+	if cmd.Ins == InsInitializeUpdate {
+		return fc.initializeUpdate(cmd.Data), nil
+	}
+
+	data := fc.unwrap(cmd)
+
+	if cmd.Ins == InsExternalAuthenticate {
+		assert.Equal(fc.t, scp03KDF(fc.mac, crypto.DerivationConstantHostCryptogram, fc.context, 64), data)
+		fc.level = cmd.P1
+		return &apdu.Response{Sw1: 0x90, Sw2: 0x00, Sw: SwOK}, nil
+	}
+
+	resp := &apdu.Response{Sw1: 0x90, Sw2: 0x00, Sw: SwOK}
+	if len(fc.responses) > 0 {
+		resp = fc.responses[0]
+		fc.responses = fc.responses[1:]
+	}
+
+	return fc.wrap(resp), nil
+}
+
+func (fc *fakeSCP03Card) initializeUpdate(hostChallenge []byte) *apdu.Response {
+	fc.context = append(append([]byte{}, hostChallenge...), fc.cardChallenge...)
+	fc.enc = scp03KDF(fc.keys.Enc, crypto.DerivationConstantSEnc, fc.context, 128)
+	fc.mac = scp03KDF(fc.keys.Mac, crypto.DerivationConstantSMac, fc.context, 128)
+	fc.rmac = scp03KDF(fc.keys.Mac, crypto.DerivationConstantSRMac, fc.context, 128)
+	fc.level = 0
+	fc.chainingValue = crypto.NullBytes16
+	fc.counter = 0
+
+	resp := hexutils.HexToBytes("00010203040506070809")
+	resp = append(resp, 0x30, SCP03, 0x00)
+	resp = append(resp, fc.cardChallenge...)
+	resp = append(resp, scp03KDF(fc.mac, crypto.DerivationConstantCardCryptogram, fc.context, 64)...)
+
+	return &apdu.Response{Data: resp, Sw1: 0x90, Sw2: 0x00, Sw: SwOK}
+}
+
+// unwrap verifies the C-MAC of cmd and returns its decrypted data.
+func (fc *fakeSCP03Card) unwrap(cmd *apdu.Command) []byte {
+	data := cmd.Data[:len(cmd.Data)-8]
+
+	macData := append(append([]byte{}, fc.chainingValue...), cmd.Cla, cmd.Ins, cmd.P1, cmd.P2, byte(len(cmd.Data)))
+	mac, err := crypto.CMAC(fc.mac, append(macData, data...))
+	assert.NoError(fc.t, err)
+	assert.Equal(fc.t, mac[:8], cmd.Data[len(cmd.Data)-8:], "bad C-MAC")
+	fc.chainingValue = mac
+
+	if fc.level&securityLevelCDec == 0 {
+		return data
+	}
+
+	fc.counter++
+	if len(data) == 0 {
+		return data
+	}
+
+	icv, err := crypto.EncryptAESBlock(fc.enc, counterBlock(0x00, fc.counter))
+	assert.NoError(fc.t, err)
+	plain, err := crypto.DecryptAESCBC(fc.enc, icv, data)
+	assert.NoError(fc.t, err)
+	plain, err = crypto.RemovePadding(plain)
+	assert.NoError(fc.t, err)
+
+	return plain
+}
+
+// wrap encrypts the data of resp and appends the R-MAC, depending on the security level.
+func (fc *fakeSCP03Card) wrap(resp *apdu.Response) *apdu.Response {
+	if fc.level&securityLevelRMAC == 0 {
+		return resp
+	}
+
+	data := resp.Data
+	if fc.level&securityLevelREnc != 0 && len(data) > 0 {
+		icv, err := crypto.EncryptAESBlock(fc.enc, counterBlock(0x80, fc.counter))
+		assert.NoError(fc.t, err)
+		data, err = crypto.EncryptAESCBC(fc.enc, icv, crypto.AppendAESPadding(data))
+		assert.NoError(fc.t, err)
+	}
+
+	macData := append(append([]byte{}, fc.chainingValue...), data...)
+	mac, err := crypto.CMAC(fc.rmac, append(macData, resp.Sw1, resp.Sw2))
+	assert.NoError(fc.t, err)
+
+	return &apdu.Response{Data: append(data, mac[:8]...), Sw1: resp.Sw1, Sw2: resp.Sw2, Sw: resp.Sw}
+}
+
+func TestCommandSet_OpenSecureChannelSCP03(t *testing.T) {
+	keys := NewKeySet(0x30, hexutils.HexToBytes("0102030405060708090a0b0c0d0e0f10"))
+	card := newFakeSCP03Card(t, keys)
+	cs := NewCommandSet(card)
+
+	// the SCP02 default keys don't match
+	assert.Equal(t, ErrNoValidKeySet, cs.OpenSecureChannel())
+
+	cs.SetKeySets(keys)
+	assert.NoError(t, cs.OpenSecureChannelWithSecurityLevel(SecurityLevelCDecREncCMACRMAC))
+	assert.Equal(t, uint8(SCP03), cs.Session().SCPVersion())
+	assert.Equal(t, uint8(0x30), cs.KeySet().Version)
+	assert.Equal(t, uint8(SecurityLevelCDecREncCMACRMAC), card.level)
+
+	card.responses = []*apdu.Response{
+		{Data: hexutils.HexToBytes("E31D4F08A0000008040001019F700107C503000000CC08A000000151000000"), Sw1: 0x63, Sw2: 0x10, Sw: SwMoreData},
+		{Data: hexutils.HexToBytes("E3114F08A0000008040001039F700183C50100"), Sw1: 0x90, Sw2: 0x00, Sw: SwOK},
+	}
+
+	apps, err := cs.GetStatusApplications()
+	assert.NoError(t, err)
+	assert.Len(t, apps, 2)
+	assert.Equal(t, "A000000804000103", hexutils.BytesToHex(apps[1].AID))
+	// EXTERNAL AUTHENTICATE is sent with C-MAC only, so only the GET STATUS commands are counted
+	assert.Equal(t, uint32(2), card.counter)
+}
+
+func TestCommandSet_OpenSecureChannelDefaultKeys(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+
+	assert.NoError(t, cs.OpenSecureChannel())
+	assert.Equal(t, DefaultKeySets()[1].Enc, cs.KeySet().Enc)
+	assert.Equal(t, uint8(0x01), cs.KeySet().Version)
+	assert.Equal(t, uint8(InsExternalAuthenticate), card.commands[1].Ins)
+	assert.Equal(t, uint8(SecurityLevelCMAC), card.commands[1].P1)
+}
+
+func TestCommandSet_OpenSecureChannelDiversifiedKeys(t *testing.T) {
+	master := NewKeySet(0x01, hexutils.HexToBytes("404142434445464748494a4b4c4d4e4f"))
+	master.Diversification = DiversificationEMVCPS
+	cardKeys, err := master.Diversify(hexutils.HexToBytes("00010203040506070809"))
+	assert.NoError(t, err)
+
+	card := newFakeCard(cardKeys)
+	cs := NewCommandSet(card)
+
+	assert.Equal(t, ErrNoValidKeySet, cs.OpenSecureChannel())
+
+	cs.SetKeySets(master)
+	assert.NoError(t, cs.OpenSecureChannel())
+	assert.Equal(t, cardKeys, cs.KeySet())
+
+	// key version doesn't match
+	card.keyVersion = 0x02
+	assert.Equal(t, ErrNoValidKeySet, cs.OpenSecureChannel())
+	assert.Nil(t, cs.KeySet())
+	assert.Nil(t, cs.SecureChannel())
+}
+
+func TestCommandSet_OpenSecureChannelSkipsKeySetsFailingDiversification(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+
+	// AES keys can't be diversified with EMV CPS
+	aes := NewKeySet(0, make([]byte, 32))
+	aes.Diversification = DiversificationEMVCPS
+	cs.SetKeySets(aes, DefaultKeySets()[1])
+
+	assert.NoError(t, cs.OpenSecureChannel())
+	assert.Equal(t, DefaultKeySets()[1].Enc, cs.KeySet().Enc)
+}
+
+func TestCommandSet_RotateKeys(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+
+	newKeys := &KeySet{
+		Version: 0x02,
+		Enc:     hexutils.HexToBytes("505152535455565758595a5b5c5d5e5f"),
+		Mac:     hexutils.HexToBytes("606162636465666768696a6b6c6d6e6f"),
+		Dek:     hexutils.HexToBytes("707172737475767778797a7b7c7d7e7f"),
+	}
+
+	assert.Equal(t, ErrSecureChannelNotOpen, cs.RotateKeys(newKeys))
+
+	assert.NoError(t, cs.OpenSecureChannel())
+	dek, err := crypto.DeriveKey(DefaultKeySets()[1].Dek, []byte{0x00, 0x65}, crypto.DerivationPurposeDek)
+	assert.NoError(t, err)
+
+	card.nextKeys = newKeys
+	card.commands = nil
+	assert.NoError(t, cs.RotateKeys(newKeys))
+
+	putKey := card.commands[0]
+	assert.Equal(t, uint8(InsPutKey), putKey.Ins)
+	assert.Equal(t, uint8(0x01), putKey.P1)
+	assert.Equal(t, uint8(0x81), putKey.P2)
+
+	encryptedEnc, err := crypto.Encrypt3DESECB(dek, newKeys.Enc)
+	assert.NoError(t, err)
+	kcv, err := crypto.KeyCheckValueDES(newKeys.Enc)
+	assert.NoError(t, err)
+	expected := append([]byte{0x02, 0x80, 0x10}, encryptedEnc...)
+	expected = append(expected, 0x03)
+	expected = append(expected, kcv...)
+	assert.Equal(t, expected, putKey.Data[:len(expected)])
+
+	// new session opened with the new keys
+	assert.Equal(t, uint8(InsInitializeUpdate), card.commands[1].Ins)
+	assert.Equal(t, uint8(0x02), card.commands[1].P1)
+	assert.Equal(t, uint8(0x02), cs.Session().KeyVersion())
+	assert.Equal(t, newKeys.Enc, cs.KeySet().Enc)
+	assert.Equal(t, uint8(0x02), cs.KeySet().Version)
+}
+
+func TestCommandSet_PutKeysBadKeyCheckValue(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+	assert.NoError(t, cs.OpenSecureChannel())
+
+	card.responses[InsPutKey] = []*apdu.Response{
+		{Data: hexutils.HexToBytes("02000000000000000000"), Sw1: 0x90, Sw2: 0x00, Sw: SwOK},
+	}
+
+	assert.Equal(t, ErrBadKeyCheckValue, cs.PutKeys(NewKeySet(0x02, identifiers.KeycardDevelopmentKey), 0))
+	assert.Equal(t, ErrInvalidKeyVersion, cs.PutKeys(NewKeySet(0x00, identifiers.KeycardDevelopmentKey), 0))
+}
+
+func TestCommandSet_GetStatusApplications(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+
+	_, err := cs.GetStatusApplications()
+	assert.Equal(t, ErrSecureChannelNotOpen, err)
+
+	assert.NoError(t, cs.OpenSecureChannel())
+
+	card.responses[InsGetStatus] = []*apdu.Response{
+		{Data: hexutils.HexToBytes("E31D4F08A0000008040001019F700107C503000000CC08A000000151000000"), Sw1: 0x63, Sw2: 0x10, Sw: SwMoreData},
+		{Data: hexutils.HexToBytes("E3114F08A0000008040001039F700183C50100"), Sw1: 0x90, Sw2: 0x00, Sw: SwOK},
+	}
+
+	card.commands = nil
+	apps, err := cs.GetStatusApplications()
+	assert.NoError(t, err)
+	assert.Len(t, apps, 2)
+	assert.Equal(t, "A000000804000103", hexutils.BytesToHex(apps[1].AID))
+
+	assert.Len(t, card.commands, 2)
+	assert.Equal(t, uint8(P1GetStatusApplications), card.commands[0].P1)
+	assert.Equal(t, uint8(P2GetStatusTLVData), card.commands[0].P2)
+	assert.Equal(t, uint8(P2GetStatusTLVData|P2GetStatusNext), card.commands[1].P2)
+}
+
+func TestCommandSet_GetStatusLoadFilesEmpty(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+	assert.NoError(t, cs.OpenSecureChannel())
+
+	card.responses[InsGetStatus] = []*apdu.Response{
+		{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound},
+	}
+
+	files, err := cs.GetStatusLoadFilesAndModules()
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestCommandSet_ListKeycardInstances(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+	assert.NoError(t, cs.OpenSecureChannel())
+
+	card.responses[InsGetStatus] = []*apdu.Response{
+		{Data: hexutils.HexToBytes("E3124F09A000000804000101019F700107C50100E3124F09A000000804000101029F700107C50100E3124F09A000000804000103019F700107C50100"), Sw1: 0x90, Sw2: 0x00, Sw: SwOK},
+	}
+
+	indexes, err := cs.ListKeycardInstances()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, indexes)
+
+	card.commands = nil
+	assert.NoError(t, cs.InstallKeycardInstance(&KeycardInstallParams{InstanceIndex: 3, DefaultSelected: true}))
+	// the command is followed by its C-MAC
+	assert.Equal(t, "07A000000804000108A00000080400010109A00000080400010103010402C90000", hexutils.BytesToHex(card.commands[0].Data[:33]))
+}
+
+func TestCommandSet_SetStatus(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+
+	assert.Equal(t, ErrSecureChannelNotOpen, cs.LockCard())
+	assert.NoError(t, cs.OpenSecureChannel())
+
+	card.commands = nil
+	assert.NoError(t, cs.LockCard())
+	assert.NoError(t, cs.UnlockCard())
+	assert.NoError(t, cs.LockApplication(identifiers.CashInstanceAID))
+	assert.NoError(t, cs.UnlockApplication(identifiers.CashInstanceAID))
+
+	assert.Equal(t, ErrNotConfirmed, cs.TerminateCard("yes"))
+	assert.Equal(t, ErrNotConfirmed, cs.PersonalizeApplication(identifiers.CashInstanceAID, ""))
+	assert.NoError(t, cs.PersonalizeApplication(identifiers.CashInstanceAID, ConfirmPersonalizeApplication))
+	assert.NoError(t, cs.TerminateCard(ConfirmTerminateCard))
+
+	expected := [][2]uint8{{0x80, 0x7F}, {0x80, 0x0F}, {0x40, 0x80}, {0x40, 0x00}, {0x40, 0x0F}, {0x80, 0xFF}}
+	assert.Len(t, card.commands, len(expected))
+	for i, cmd := range card.commands {
+		assert.Equal(t, uint8(InsSetStatus), cmd.Ins)
+		assert.Equal(t, expected[i], [2]uint8{cmd.P1, cmd.P2})
+	}
+
+	card.responses[InsSetStatus] = []*apdu.Response{{Sw1: 0x69, Sw2: 0x85, Sw: 0x6985}}
+	assert.Error(t, cs.LockCard())
+}
+
+func TestCommandSet_GetData(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+
+	card.responses[InsGetData] = []*apdu.Response{
+		{Data: hexutils.HexToBytes("663E733C06072A864886FC6B01600C060A2A864886FC6B02020201630906072A864886FC6B03640B06092A864886FC6B040215640B06092A864886FC6B040310"), Sw1: 0x90, Sw2: 0x00, Sw: SwOK},
+		{Data: hexutils.HexToBytes("E006C00401018010"), Sw1: 0x90, Sw2: 0x00, Sw: SwOK},
+		{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound},
+	}
+
+	// card recognition data is readable without a secure channel
+	crd, err := cs.GetCardRecognitionData()
+	assert.NoError(t, err)
+	assert.True(t, crd.SupportsSCP(SCP02))
+	assert.Equal(t, uint8(P1P2GetDataCardData), card.commands[0].P2)
+
+	assert.NoError(t, cs.OpenSecureChannel())
+	keys, err := cs.GetKeyInformation()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x01), keys[0].Version)
+
+	_, err = cs.GetCPLC()
+	assert.Error(t, err)
+}
+
+func TestCommandSet_KeyVersion(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	card.keyVersion = 0x20
+	cs := NewCommandSet(card)
+
+	cs.SetKeyVersion(0x21)
+	err := cs.OpenSecureChannel()
+	assert.Equal(t, &KeyVersionNotFoundError{Version: 0x21}, err)
+	assert.EqualError(t, err, "key version 33 not found on the card")
+	assert.Nil(t, cs.Session())
+
+	cs.SetKeyVersion(0x20)
+	assert.NoError(t, cs.OpenSecureChannel())
+	assert.Equal(t, uint8(0x20), card.commands[len(card.commands)-2].P1)
+
+	session := cs.Session()
+	assert.Equal(t, uint8(0x20), session.KeyVersion())
+	assert.Equal(t, card.divData, session.DiversificationData())
+	assert.Equal(t, []byte{0x00, 0x65}, session.SequenceCounter())
+
+	// without an explicit key version, 6A88 is an unexpected response
+	cs.SetKeyVersion(0)
+	card.responses[InsInitializeUpdate] = []*apdu.Response{{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound}}
+	err = cs.OpenSecureChannel()
+	assert.Equal(t, apdu.NewErrBadResponse(SwReferencedDataNotFound, "unexpected response"), err)
+}
+
+func TestCommandSet_Invalidate(t *testing.T) {
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+	assert.NoError(t, cs.OpenSecureChannel())
+
+	cs.Invalidate()
+	assert.Nil(t, cs.Session())
+	assert.Nil(t, cs.KeySet())
+
+	_, err := cs.GetStatusApplications()
+	assert.Equal(t, ErrSecureChannelNotOpen, err)
+
+	_, err = cs.GetStatus()
+	assert.Equal(t, ErrSecureChannelNotOpen, err)
+
+	err = cs.InstallForInstall(identifiers.PackageAID, identifiers.KeycardAID, identifiers.KeycardAID, nil)
+	assert.Equal(t, ErrSecureChannelNotOpen, err)
+}
@@ -19,6 +19,9 @@ const (
 	InsLoad                 = 0xE8
 	InsInstall              = 0xE6
 	InsGetStatus            = 0xF2
+	InsPutKey               = 0xD8
+	InsSetStatus            = 0xF0
+	InsGetData              = 0xCA
 
 	P1ExternalAuthenticateCMAC         = 0x01
 	P1InstallForLoad                   = 0x02
@@ -30,19 +33,44 @@ const (
 	P1GetStatusApplications            = 0x40
 	P1GetStatusExecLoadFiles           = 0x20
 	P1GetStatusExecLoadFilesAndModules = 0x10
+	P1SetStatusIssuerSecurityDomain    = 0x80
+	P1SetStatusApplication             = 0x40
 
 	P2GetStatusTLVData             = 0x02
+	P2GetStatusNext                = 0x01
 	P2DeleteObject                 = 0x00
 	P2DeleteObjectAndRelatedObject = 0x80
+	P2PutKeyMultipleKeys           = 0x80
+	P2SetStatusCardSecured         = 0x0F
+	P2SetStatusCardLocked          = 0x7F
+	P2SetStatusCardTerminated      = 0xFF
+	P2SetStatusApplicationUnlocked = 0x00
+	P2SetStatusApplicationLocked   = 0x80
+	P2SetStatusPersonalized        = 0x0F
+
+	P1P2GetDataCPLC           = 0x9F7F
+	P1P2GetDataCardData       = 0x0066
+	P1P2GetDataKeyInformation = 0x00E0
+
+	SecurityLevelCMAC             = 0x01
+	SecurityLevelCDecCMAC         = 0x03
+	SecurityLevelCMACRMAC         = 0x11
+	SecurityLevelCDecCMACRMAC     = 0x13
+	SecurityLevelCDecREncCMACRMAC = 0x33
 
 	Sw1ResponseDataIncomplete = 0x61
 
 	SwOK                            = 0x9000
+	SwMoreData                      = 0x6310
 	SwFileNotFound                  = 0x6A82
 	SwReferencedDataNotFound        = 0x6A88
 	SwSecurityConditionNotSatisfied = 0x6982
 	SwAuthenticationMethodBlocked   = 0x6983
 
+	securityLevelCDec = 0x02
+	securityLevelRMAC = 0x10
+	securityLevelREnc = 0x20
+
 	tagDeleteAID         = 0x4F
 	tagLoadFileDataBlock = 0xC4
 	tagGetStatusAID      = 0x4F
@@ -63,10 +91,16 @@ func NewCommandSelect(aid []byte) *apdu.Command {
 
 // NewCommandInitializeUpdate returns an Initialize Update command as defined in the globalplatform specifications.
 func NewCommandInitializeUpdate(challenge []byte) *apdu.Command {
+	return NewCommandInitializeUpdateWithKeyVersion(challenge, 0)
+}
+
+// NewCommandInitializeUpdateWithKeyVersion returns an Initialize Update command selecting the specified key version.
+// Key version 0 lets the card use its first available key set.
+func NewCommandInitializeUpdateWithKeyVersion(challenge []byte, keyVersion uint8) *apdu.Command {
 	c := apdu.NewCommand(
 		ClaGp,
 		InsInitializeUpdate,
-		0,
+		keyVersion,
 		0,
 		challenge,
 	)
@@ -94,6 +128,18 @@ func NewCommandExternalAuthenticate(encKey, cardChallenge, hostChallenge []byte)
 	), nil
 }
 
+// NewCommandExternalAuthenticateWithCryptogram returns an External Authenticate command
+// with an already computed host cryptogram and the specified security level.
+func NewCommandExternalAuthenticateWithCryptogram(securityLevel uint8, hostCryptogram []byte) *apdu.Command {
+	return apdu.NewCommand(
+		ClaMac,
+		InsExternalAuthenticate,
+		securityLevel,
+		0,
+		hostCryptogram,
+	)
+}
+
 // NewCommandGetResponse returns a Get Response command as defined in the globalplatform specifications.
 func NewCommandGetResponse(length uint8) *apdu.Command {
 	c := apdu.NewCommand(
@@ -123,14 +169,49 @@ func NewCommandDelete(aid []byte, p2 uint8) *apdu.Command {
 	)
 }
 
+// NewCommandSetStatus returns a Set Status command as defined in the globalplatform specifications.
+// p1 is the status type and p2 the new state. The aid is empty for the issuer security domain.
+func NewCommandSetStatus(p1, p2 uint8, aid []byte) *apdu.Command {
+	return apdu.NewCommand(
+		ClaGp,
+		InsSetStatus,
+		p1,
+		p2,
+		aid,
+	)
+}
+
+// NewCommandGetData returns a Get Data command for the data object identified by p1p2.
+func NewCommandGetData(p1p2 uint16) *apdu.Command {
+	cmd := apdu.NewCommand(
+		ClaGp,
+		InsGetData,
+		uint8(p1p2>>8),
+		uint8(p1p2),
+		[]byte{},
+	)
+
+	cmd.SetLe(0)
+
+	return cmd
+}
+
 // NewCommandInstallForLoad returns an Install command with the install-for-load parameter as defined in the globalplatform specifications.
 func NewCommandInstallForLoad(aid, sdaid []byte) *apdu.Command {
+	return NewCommandInstallForLoadWithHash(aid, sdaid, []byte{})
+}
+
+// NewCommandInstallForLoadWithHash returns an Install command with the install-for-load parameter
+// and the hash of the load file data block.
+func NewCommandInstallForLoadWithHash(aid, sdaid, hash []byte) *apdu.Command {
 	data := []byte{byte(len(aid))}
 	data = append(data, aid...)
 	data = append(data, byte(len(sdaid)))
 	data = append(data, sdaid...)
-	// empty hash length and hash
-	data = append(data, []byte{0x00, 0x00, 0x00}...)
+	data = append(data, byte(len(hash)))
+	data = append(data, hash...)
+	// empty load parameters and token
+	data = append(data, []byte{0x00, 0x00}...)
 
 	return apdu.NewCommand(
 		ClaGp,
@@ -142,7 +223,14 @@ func NewCommandInstallForLoad(aid, sdaid []byte) *apdu.Command {
 }
 
 // NewCommandInstallForInstall returns an Install command with the install-for-instalp parameter as defined in the globalplatform specifications.
+// The instance is installed without privileges and with params as the application specific parameters.
 func NewCommandInstallForInstall(pkgAID, appletAID, instanceAID, params []byte) *apdu.Command {
+	return NewCommandInstallForInstallWithParams(pkgAID, appletAID, instanceAID, NewInstallParams(params))
+}
+
+// NewCommandInstallForInstallWithParams returns an Install command with the install-for-install parameter,
+// the privileges, the system and application parameters and the install token specified in params.
+func NewCommandInstallForInstallWithParams(pkgAID, appletAID, instanceAID []byte, params *InstallParams) *apdu.Command {
 	data := []byte{byte(len(pkgAID))}
 	data = append(data, pkgAID...)
 	data = append(data, byte(len(appletAID)))
@@ -150,20 +238,16 @@ func NewCommandInstallForInstall(pkgAID, appletAID, instanceAID, params []byte)
 	data = append(data, byte(len(instanceAID)))
 	data = append(data, instanceAID...)
 
-	// privileges
-	priv := []byte{0x00}
+	priv := params.Privileges.Bytes()
 	data = append(data, byte(len(priv)))
 	data = append(data, priv...)
 
-	// params
-	fullParams := []byte{byte(0xC9), byte(len(params))}
-	fullParams = append(fullParams, params...)
-
+	fullParams := params.Encode()
 	data = append(data, byte(len(fullParams)))
 	data = append(data, fullParams...)
 
-	// empty perform token
-	data = append(data, byte(0x00))
+	data = append(data, byte(len(params.InstallToken)))
+	data = append(data, params.InstallToken...)
 
 	return apdu.NewCommand(
 		ClaGp,
@@ -189,6 +273,23 @@ func NewCommandGetStatus(aid []byte, p1 uint8) *apdu.Command {
 	)
 }
 
+// NewCommandPutKey returns a Put Key command as defined in the globalplatform specifications.
+// If replaceVersion is 0 a new key version is added, otherwise the keys with that version are replaced.
+// The data contains the new key version followed by the encrypted key components.
+func NewCommandPutKey(replaceVersion uint8, keyID uint8, data []byte) *apdu.Command {
+	c := apdu.NewCommand(
+		ClaGp,
+		InsPutKey,
+		replaceVersion,
+		keyID,
+		data,
+	)
+
+	c.SetLe(0)
+
+	return c
+}
+
 func calculateHostCryptogram(encKey, cardChallenge, hostChallenge []byte) ([]byte, error) {
 	var data []byte
 	data = append(data, cardChallenge...)
@@ -106,3 +106,35 @@ func TestNewCommandStatus(t *testing.T) {
 	expected := "4F03AABBCC"
 	assert.Equal(t, expected, hexutils.BytesToHex(cmd.Data))
 }
+
+func TestNewCommandSetStatus(t *testing.T) {
+	aid := hexutils.HexToBytes("A00000080400010101")
+	cmd := NewCommandSetStatus(P1SetStatusApplication, P2SetStatusApplicationLocked, aid)
+	assert.Equal(t, uint8(0x80), cmd.Cla)
+	assert.Equal(t, uint8(0xF0), cmd.Ins)
+	assert.Equal(t, uint8(0x40), cmd.P1)
+	assert.Equal(t, uint8(0x80), cmd.P2)
+	assert.Equal(t, aid, cmd.Data)
+}
+
+func TestNewCommandGetData(t *testing.T) {
+	cmd := NewCommandGetData(P1P2GetDataCPLC)
+	assert.Equal(t, uint8(0x80), cmd.Cla)
+	assert.Equal(t, uint8(0xCA), cmd.Ins)
+	assert.Equal(t, uint8(0x9F), cmd.P1)
+	assert.Equal(t, uint8(0x7F), cmd.P2)
+
+	_, le := cmd.Le()
+	assert.Equal(t, uint8(0x00), le)
+}
+
+func TestNewCommandInitializeUpdateWithKeyVersion(t *testing.T) {
+	challenge := hexutils.HexToBytes("0001020304050607")
+	cmd := NewCommandInitializeUpdateWithKeyVersion(challenge, 0x30)
+	assert.Equal(t, uint8(0x50), cmd.Ins)
+	assert.Equal(t, uint8(0x30), cmd.P1)
+	assert.Equal(t, uint8(0x00), cmd.P2)
+	assert.Equal(t, challenge, cmd.Data)
+
+	assert.Equal(t, uint8(0x00), NewCommandInitializeUpdate(challenge).P1)
+}
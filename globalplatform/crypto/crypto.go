@@ -11,6 +11,10 @@ var (
 	DerivationPurposeEnc = []byte{0x01, 0x82}
 	// DerivationPurposeMac defines 2 bytes used when deriving a mac key.
 	DerivationPurposeMac = []byte{0x01, 0x01}
+	// DerivationPurposeRMac defines 2 bytes used when deriving a response mac key.
+	DerivationPurposeRMac = []byte{0x01, 0x02}
+	// DerivationPurposeDek defines 2 bytes used when deriving a data encryption key.
+	DerivationPurposeDek = []byte{0x01, 0x81}
 	// NullBytes8 defined a slice of 8 zero bytes mostrly used as IV in cryptographic functions.
 	NullBytes8 = []byte{0, 0, 0, 0, 0, 0, 0, 0}
 )
@@ -114,6 +118,45 @@ func Mac3DES(key, data, iv []byte) ([]byte, error) {
 	return ciphertext[16:], nil
 }
 
+// Encrypt3DESCBC encrypts data, which must be padded to the DES block size, using triple DES in CBC mode.
+func Encrypt3DESCBC(key, iv, data []byte) ([]byte, error) {
+	block, err := des.NewTripleDESCipher(resizeKey24(key))
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := make([]byte, len(data))
+	mode := cipher.NewCBCEncrypter(block, iv)
+	mode.CryptBlocks(ciphertext, data)
+
+	return ciphertext, nil
+}
+
+// Encrypt3DESECB encrypts data, which must be padded to the DES block size, using triple DES in ECB mode.
+func Encrypt3DESECB(key, data []byte) ([]byte, error) {
+	block, err := des.NewTripleDESCipher(resizeKey24(key))
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := make([]byte, len(data))
+	for i := 0; i < len(data); i += des.BlockSize {
+		block.Encrypt(ciphertext[i:i+des.BlockSize], data[i:i+des.BlockSize])
+	}
+
+	return ciphertext, nil
+}
+
+// KeyCheckValueDES returns the 3 bytes check value of a triple DES key.
+func KeyCheckValueDES(key []byte) ([]byte, error) {
+	kcv, err := Encrypt3DESECB(key, NullBytes8)
+	if err != nil {
+		return nil, err
+	}
+
+	return kcv[:3], nil
+}
+
 // AppendDESPadding appends an 0x80 bytes to data and other zero bytes to make the result length multiple of 8.
 func AppendDESPadding(data []byte) []byte {
 	blockSize := 8
@@ -71,3 +71,14 @@ func TestMacFull3DES(t *testing.T) {
 	expected := "5271D7174A5A166A"
 	assert.Equal(t, expected, hexutils.BytesToHex(result))
 }
+
+func TestKeyCheckValueDES(t *testing.T) {
+	key := hexutils.HexToBytes("505152535455565758595a5b5c5d5e5f")
+	kcv, err := KeyCheckValueDES(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "A4B7D6", hexutils.BytesToHex(kcv))
+
+	encrypted, err := Encrypt3DESECB(hexutils.HexToBytes("404142434445464748494a4b4c4d4e4f"), key)
+	assert.NoError(t, err)
+	assert.Equal(t, "6C242DC05240ED834127E298A97B574D", hexutils.BytesToHex(encrypted))
+}
@@ -0,0 +1,203 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+)
+
+// Derivation constants used by the SCP03 key derivation function.
+const (
+	DerivationConstantCardCryptogram = 0x00
+	DerivationConstantHostCryptogram = 0x01
+	DerivationConstantCardChallenge  = 0x02
+	DerivationConstantSEnc           = 0x04
+	DerivationConstantSMac           = 0x06
+	DerivationConstantSRMac          = 0x07
+)
+
+var (
+	// NullBytes16 defines a slice of 16 zero bytes used as IV and initial MAC chaining value in SCP03.
+	NullBytes16 = make([]byte, 16)
+
+	ErrInvalidPadding = errors.New("invalid padding")
+)
+
+const cmacRb = 0x87
+
+// CMAC generates the AES-CMAC of data as defined in NIST SP 800-38B.
+func CMAC(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	k1, k2 := cmacSubkeys(block)
+
+	var last []byte
+	n := len(data)
+	if n > 0 && n%aes.BlockSize == 0 {
+		last = xorBytes(data[n-aes.BlockSize:], k1)
+		data = data[:n-aes.BlockSize]
+	} else {
+		rest := n % aes.BlockSize
+		last = xorBytes(AppendAESPadding(data[n-rest:])[:aes.BlockSize], k2)
+		data = data[:n-rest]
+	}
+
+	mac := make([]byte, aes.BlockSize)
+	for i := 0; i < len(data); i += aes.BlockSize {
+		block.Encrypt(mac, xorBytes(mac, data[i:i+aes.BlockSize]))
+	}
+
+	block.Encrypt(mac, xorBytes(mac, last))
+
+	return mac, nil
+}
+
+func cmacSubkeys(block cipher.Block) ([]byte, []byte) {
+	l := make([]byte, aes.BlockSize)
+	block.Encrypt(l, l)
+
+	k1 := shiftLeft(l)
+	k2 := shiftLeft(k1)
+
+	return k1, k2
+}
+
+func shiftLeft(in []byte) []byte {
+	out := make([]byte, len(in))
+	var carry byte
+	for i := len(in) - 1; i >= 0; i-- {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+
+	if in[0]&0x80 != 0 {
+		out[len(out)-1] ^= cmacRb
+	}
+
+	return out
+}
+
+func xorBytes(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+
+	return out
+}
+
+// DeriveKeySCP03 implements the NIST SP 800-108 KDF in counter mode with AES-CMAC as PRF,
+// as used by SCP03 to derive session keys and cryptograms.
+// The returned value is bits long.
+func DeriveKeySCP03(key []byte, constant byte, context []byte, bits int) ([]byte, error) {
+	data := make([]byte, 16, 16+len(context))
+	// 11 bytes label set to zero followed by the derivation constant
+	data[11] = constant
+	// data[12] is the separation indicator, always zero
+	binary.BigEndian.PutUint16(data[13:15], uint16(bits))
+	data = append(data, context...)
+
+	size := bits / 8
+	out := make([]byte, 0, size+aes.BlockSize)
+	for i := byte(1); len(out) < size; i++ {
+		data[15] = i
+		mac, err := CMAC(key, data)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, mac...)
+	}
+
+	return out[:size], nil
+}
+
+// EncryptAESCBC encrypts data, which must be padded to the AES block size, using AES in CBC mode.
+func EncryptAESCBC(key, iv, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := make([]byte, len(data))
+	mode := cipher.NewCBCEncrypter(block, iv)
+	mode.CryptBlocks(ciphertext, data)
+
+	return ciphertext, nil
+}
+
+// DecryptAESCBC decrypts data using AES in CBC mode. Padding is not removed.
+func DecryptAESCBC(key, iv, data []byte) ([]byte, error) {
+	if len(data)%aes.BlockSize != 0 {
+		return nil, ErrInvalidPadding
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(data))
+	mode := cipher.NewCBCDecrypter(block, iv)
+	mode.CryptBlocks(plaintext, data)
+
+	return plaintext, nil
+}
+
+// EncryptAESBlock encrypts a single 16 bytes block using AES in ECB mode.
+func EncryptAESBlock(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := make([]byte, aes.BlockSize)
+	block.Encrypt(ciphertext, data)
+
+	return ciphertext, nil
+}
+
+// KeyCheckValueAES returns the 3 bytes check value of an AES key.
+func KeyCheckValueAES(key []byte) ([]byte, error) {
+	data := make([]byte, aes.BlockSize)
+	for i := range data {
+		data[i] = 0x01
+	}
+
+	kcv, err := EncryptAESBlock(key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return kcv[:3], nil
+}
+
+// AppendAESPadding appends an 0x80 bytes to data and other zero bytes to make the result length multiple of 16.
+func AppendAESPadding(data []byte) []byte {
+	paddingSize := aes.BlockSize - (len(data) % aes.BlockSize)
+	newData := make([]byte, len(data)+paddingSize)
+	copy(newData, data)
+	newData[len(data)] = 0x80
+
+	return newData
+}
+
+// RemovePadding removes the 0x80 padding added by AppendDESPadding or AppendAESPadding.
+func RemovePadding(data []byte) ([]byte, error) {
+	for i := len(data) - 1; i >= 0; i-- {
+		switch data[i] {
+		case 0x00:
+			continue
+		case 0x80:
+			return data[:i], nil
+		default:
+			return nil, ErrInvalidPadding
+		}
+	}
+
+	return nil, ErrInvalidPadding
+}
@@ -0,0 +1,114 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCMAC(t *testing.T) {
+	// test vectors from RFC 4493
+	key := hexutils.HexToBytes("2b7e151628aed2a6abf7158809cf4f3c")
+	message := "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+
+	scenarios := []struct {
+		length   int
+		expected string
+	}{
+		{0, "BB1D6929E95937287FA37D129B756746"},
+		{16, "070A16B46B4D4144F79BDD9DD04A287C"},
+		{40, "DFA66747DE9AE63030CA32611497C827"},
+		{64, "51F0BEBF7E3B9D92FC49741779363CFE"},
+	}
+
+	for _, s := range scenarios {
+		data := hexutils.HexToBytes(message)[:s.length]
+		mac, err := CMAC(key, data)
+		assert.NoError(t, err)
+		assert.Equal(t, s.expected, hexutils.BytesToHex(mac), "length %d", s.length)
+	}
+}
+
+func TestDeriveKeySCP03(t *testing.T) {
+	// expected values computed with the KBKDFCMAC of pyca/cryptography in counter mode,
+	// with the 1 byte counter placed after the 12 bytes label, the separator and L
+	key := hexutils.HexToBytes("404142434445464748494a4b4c4d4e4f")
+	context := hexutils.HexToBytes("00010203040506071011121314151617")
+
+	encKey, err := DeriveKeySCP03(key, DerivationConstantSEnc, context, 128)
+	assert.NoError(t, err)
+	assert.Equal(t, "8AAC6B39A7C426423400D8B5D9C0ADC3", hexutils.BytesToHex(encKey))
+
+	macKey, err := DeriveKeySCP03(key, DerivationConstantSMac, context, 128)
+	assert.NoError(t, err)
+	assert.Equal(t, "A2E96754F593E7E80E695BEC50A24283", hexutils.BytesToHex(macKey))
+
+	rmacKey, err := DeriveKeySCP03(key, DerivationConstantSRMac, context, 128)
+	assert.NoError(t, err)
+	assert.Equal(t, "983882695EE1AC61743415AB536CE1BE", hexutils.BytesToHex(rmacKey))
+
+	cardCryptogram, err := DeriveKeySCP03(macKey, DerivationConstantCardCryptogram, context, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "A330F60B35761CBF", hexutils.BytesToHex(cardCryptogram))
+
+	hostCryptogram, err := DeriveKeySCP03(macKey, DerivationConstantHostCryptogram, context, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "C28A6A8B99435E37", hexutils.BytesToHex(hostCryptogram))
+
+	// AES-256 keys need two iterations
+	key256 := append(key, key...)
+	encKey, err = DeriveKeySCP03(key256, DerivationConstantSEnc, context, 256)
+	assert.NoError(t, err)
+	assert.Equal(t, "ECED9E7658E55ACAD487A3FED9B116DE33FC09EEACF00D91727D5C4531CBDD84", hexutils.BytesToHex(encKey))
+}
+
+func TestDeriveKeySCP03_DerivationData(t *testing.T) {
+	key := hexutils.HexToBytes("404142434445464748494a4b4c4d4e4f")
+	context := hexutils.HexToBytes("00010203040506071011121314151617")
+
+	// derivation data of GP Amendment D 4.1.5: label, separation indicator, L, i, context
+	data := hexutils.HexToBytes("0000000000000000000000" + "06" + "00" + "0080" + "01")
+	data = append(data, context...)
+	expected, err := CMAC(key, data)
+	assert.NoError(t, err)
+
+	macKey, err := DeriveKeySCP03(key, DerivationConstantSMac, context, 128)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, macKey)
+}
+
+func TestAESCBC(t *testing.T) {
+	key := hexutils.HexToBytes("404142434445464748494a4b4c4d4e4f")
+	data := AppendAESPadding(hexutils.HexToBytes("0102030405"))
+	assert.Equal(t, "01020304058000000000000000000000", hexutils.BytesToHex(data))
+
+	ciphertext, err := EncryptAESCBC(key, NullBytes16, data)
+	assert.NoError(t, err)
+
+	plaintext, err := DecryptAESCBC(key, NullBytes16, ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, data, plaintext)
+
+	unpadded, err := RemovePadding(plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, "0102030405", hexutils.BytesToHex(unpadded))
+}
+
+func TestRemovePadding(t *testing.T) {
+	_, err := RemovePadding(hexutils.HexToBytes("0102030000000000"))
+	assert.Equal(t, ErrInvalidPadding, err)
+
+	_, err = RemovePadding([]byte{})
+	assert.Equal(t, ErrInvalidPadding, err)
+
+	data, err := RemovePadding(hexutils.HexToBytes("AABB800000000000"))
+	assert.NoError(t, err)
+	assert.Equal(t, "AABB", hexutils.BytesToHex(data))
+}
+
+func TestKeyCheckValueAES(t *testing.T) {
+	kcv, err := KeyCheckValueAES(hexutils.HexToBytes("505152535455565758595a5b5c5d5e5f"))
+	assert.NoError(t, err)
+	assert.Equal(t, "F2A8DF", hexutils.BytesToHex(kcv))
+}
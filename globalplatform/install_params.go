@@ -0,0 +1,177 @@
+package globalplatform
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/status-im/keycard-go/identifiers"
+)
+
+// Privileges are the privileges of an application as defined in the globalplatform specifications.
+// The first byte of the 3-byte encoding is the most significant one.
+type Privileges uint32
+
+// Application privileges.
+const (
+	PrivilegeSecurityDomain          Privileges = 0x800000
+	PrivilegeDAPVerification         Privileges = 0xC00000
+	PrivilegeDelegatedManagement     Privileges = 0xA00000
+	PrivilegeCardLock                Privileges = 0x100000
+	PrivilegeCardTerminate           Privileges = 0x080000
+	PrivilegeCardReset               Privileges = 0x040000
+	PrivilegeCVMManagement           Privileges = 0x020000
+	PrivilegeMandatedDAPVerification Privileges = 0xC10000
+
+	PrivilegeTrustedPath          Privileges = 0x008000
+	PrivilegeAuthorizedManagement Privileges = 0x004000
+	PrivilegeTokenVerification    Privileges = 0x002000
+	PrivilegeGlobalDelete         Privileges = 0x001000
+	PrivilegeGlobalLock           Privileges = 0x000800
+	PrivilegeGlobalRegistry       Privileges = 0x000400
+	PrivilegeFinalApplication     Privileges = 0x000200
+	PrivilegeGlobalService        Privileges = 0x000100
+
+	PrivilegeReceiptGeneration         Privileges = 0x000080
+	PrivilegeCipheredLoadFileDataBlock Privileges = 0x000040
+	PrivilegeContactlessActivation     Privileges = 0x000020
+	PrivilegeContactlessSelfActivation Privileges = 0x000010
+
+	// PrivilegeDefaultSelected is the name of PrivilegeCardReset before globalplatform 2.2.
+	PrivilegeDefaultSelected = PrivilegeCardReset
+)
+
+const (
+	tagSystemParams            = 0xEF
+	tagVolatileMemoryQuota     = 0xC7
+	tagNonVolatileMemoryQuota  = 0xC8
+	tagImplicitSelectionParams = 0xCF
+	tagApplicationParams       = 0xC9
+
+	maxInstallParamsLength = 0xFF
+)
+
+var ErrInstallParamsTooLong = errors.New("install parameters too long")
+
+// Bytes returns the privileges encoded in 1 byte, if only the first byte is used, or in 3 bytes.
+func (p Privileges) Bytes() []byte {
+	b := []byte{byte(p >> 16), byte(p >> 8), byte(p)}
+	if b[1] == 0x00 && b[2] == 0x00 {
+		return b[:1]
+	}
+
+	return b
+}
+
+// Has returns true if all the specified privileges are set.
+func (p Privileges) Has(privileges Privileges) bool {
+	return p&privileges == privileges
+}
+
+// ParsePrivileges parses privileges encoded in 1 or 3 bytes.
+func ParsePrivileges(data []byte) Privileges {
+	var p Privileges
+	for i := 0; i < 3 && i < len(data); i++ {
+		p |= Privileges(data[i]) << (16 - 8*uint(i))
+	}
+
+	return p
+}
+
+// InstallParams are the parameters of an INSTALL [for install] command.
+type InstallParams struct {
+	Privileges Privileges
+	// VolatileMemoryQuota is sent in the system parameters if not zero.
+	VolatileMemoryQuota uint16
+	// NonVolatileMemoryQuota is sent in the system parameters if not zero.
+	NonVolatileMemoryQuota uint16
+	// ImplicitSelection is the implicit selection parameter sent in the system parameters, if not empty.
+	ImplicitSelection []byte
+	// ApplicationParams are the applet specific parameters passed to its install method.
+	ApplicationParams []byte
+	// InstallToken is the token of a delegated management install. It's empty otherwise.
+	InstallToken []byte
+}
+
+// NewInstallParams returns InstallParams with no privileges and the specified application params.
+func NewInstallParams(applicationParams []byte) *InstallParams {
+	return &InstallParams{
+		ApplicationParams: applicationParams,
+	}
+}
+
+// Validate checks that the encoded parameters and the install token fit in the command.
+func (p *InstallParams) Validate() error {
+	if len(p.ImplicitSelection) > maxInstallParamsLength ||
+		len(p.ApplicationParams) > maxInstallParamsLength ||
+		len(p.Encode()) > maxInstallParamsLength ||
+		len(p.InstallToken) > maxInstallParamsLength {
+		return ErrInstallParamsTooLong
+	}
+
+	return nil
+}
+
+// Encode returns the install parameters field, with the system parameters followed by the application parameters.
+func (p *InstallParams) Encode() []byte {
+	system := make([]byte, 0)
+	if p.VolatileMemoryQuota != 0 {
+		system = appendUint16TLV(system, tagVolatileMemoryQuota, p.VolatileMemoryQuota)
+	}
+
+	if p.NonVolatileMemoryQuota != 0 {
+		system = appendUint16TLV(system, tagNonVolatileMemoryQuota, p.NonVolatileMemoryQuota)
+	}
+
+	if len(p.ImplicitSelection) > 0 {
+		system = append(system, tagImplicitSelectionParams, byte(len(p.ImplicitSelection)))
+		system = append(system, p.ImplicitSelection...)
+	}
+
+	data := make([]byte, 0)
+	if len(system) > 0 {
+		data = append(data, tagSystemParams, byte(len(system)))
+		data = append(data, system...)
+	}
+
+	data = append(data, tagApplicationParams, byte(len(p.ApplicationParams)))
+	data = append(data, p.ApplicationParams...)
+
+	return data
+}
+
+func appendUint16TLV(data []byte, tag uint8, value uint16) []byte {
+	v := make([]byte, 2)
+	binary.BigEndian.PutUint16(v, value)
+
+	data = append(data, tag, byte(len(v)))
+	return append(data, v...)
+}
+
+// KeycardInstallParams are the parameters of a Keycard applet instance.
+type KeycardInstallParams struct {
+	// InstanceIndex is the index of the instance, appended to the Keycard applet AID.
+	InstanceIndex int
+	// DefaultSelected makes the instance selected on reset, which is needed on contactless-only cards.
+	DefaultSelected bool
+	Privileges      Privileges
+	// ApplicationParams are passed as they are to the applet.
+	ApplicationParams []byte
+}
+
+// InstanceAID returns the AID of the Keycard instance.
+func (k *KeycardInstallParams) InstanceAID() ([]byte, error) {
+	return identifiers.KeycardInstanceAID(k.InstanceIndex)
+}
+
+// InstallParams returns the INSTALL [for install] parameters of the Keycard instance.
+func (k *KeycardInstallParams) InstallParams() *InstallParams {
+	privileges := k.Privileges
+	if k.DefaultSelected {
+		privileges |= PrivilegeDefaultSelected
+	}
+
+	return &InstallParams{
+		Privileges:        privileges,
+		ApplicationParams: k.ApplicationParams,
+	}
+}
@@ -0,0 +1,62 @@
+package globalplatform
+
+import (
+	"testing"
+
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/status-im/keycard-go/identifiers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivileges(t *testing.T) {
+	assert.Equal(t, []byte{0x00}, Privileges(0).Bytes())
+	assert.Equal(t, []byte{0x04}, PrivilegeDefaultSelected.Bytes())
+	assert.Equal(t, []byte{0x14, 0x00, 0x20}, (PrivilegeCardLock | PrivilegeCardReset | PrivilegeContactlessActivation).Bytes())
+
+	p := ParsePrivileges([]byte{0x9E, 0xFE, 0x80})
+	assert.True(t, p.Has(PrivilegeSecurityDomain|PrivilegeCardLock|PrivilegeReceiptGeneration))
+	assert.False(t, p.Has(PrivilegeDAPVerification))
+	assert.Equal(t, PrivilegeCardReset, ParsePrivileges([]byte{0x04}))
+}
+
+func TestInstallParams_Encode(t *testing.T) {
+	p := NewInstallParams([]byte{0xAA, 0xBB})
+	assert.Equal(t, "C902AABB", hexutils.BytesToHex(p.Encode()))
+
+	p.VolatileMemoryQuota = 0x0100
+	p.NonVolatileMemoryQuota = 0x2000
+	p.ImplicitSelection = []byte{0x81}
+	assert.Equal(t, "EF0BC7020100C8022000CF0181C902AABB", hexutils.BytesToHex(p.Encode()))
+	assert.NoError(t, p.Validate())
+
+	p.ApplicationParams = make([]byte, 250)
+	assert.Equal(t, ErrInstallParamsTooLong, p.Validate())
+}
+
+func TestNewCommandInstallForInstallWithParams(t *testing.T) {
+	params := &InstallParams{
+		Privileges:          PrivilegeCardReset | PrivilegeContactlessActivation,
+		VolatileMemoryQuota: 0x0100,
+		ApplicationParams:   []byte{0x01},
+		InstallToken:        []byte{0xCC, 0xDD},
+	}
+
+	cmd := NewCommandInstallForInstallWithParams(identifiers.PackageAID, identifiers.NdefAID, identifiers.NdefInstanceAID, params)
+	assert.Equal(t, uint8(0x0C), cmd.P1)
+
+	expected := "07A000000804000108A00000080400010207D2760000850101" + "03040020" + "09EF04C7020100C90101" + "02CCDD"
+	assert.Equal(t, expected, hexutils.BytesToHex(cmd.Data))
+}
+
+func TestKeycardInstallParams(t *testing.T) {
+	k := &KeycardInstallParams{InstanceIndex: 2, DefaultSelected: true}
+
+	aid, err := k.InstanceAID()
+	assert.NoError(t, err)
+	assert.Equal(t, "A00000080400010102", hexutils.BytesToHex(aid))
+	assert.Equal(t, PrivilegeDefaultSelected, k.InstallParams().Privileges)
+
+	k.InstanceIndex = 0
+	_, err = k.InstanceAID()
+	assert.Equal(t, identifiers.ErrInvalidInstanceIndex, err)
+}
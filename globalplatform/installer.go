@@ -0,0 +1,328 @@
+package globalplatform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/status-im/keycard-go/capfile"
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/status-im/keycard-go/identifiers"
+	"github.com/status-im/keycard-go/types"
+)
+
+var ErrAppletNotInPackage = errors.New("applet not found in the CAP file")
+
+// InstallAction is the kind of operation performed by an InstallStep.
+type InstallAction uint8
+
+const (
+	ActionDelete InstallAction = iota
+	ActionLoad
+	ActionInstall
+	ActionVerify
+)
+
+func (a InstallAction) String() string {
+	switch a {
+	case ActionDelete:
+		return "DELETE"
+	case ActionLoad:
+		return "LOAD"
+	case ActionInstall:
+		return "INSTALL"
+	case ActionVerify:
+		return "VERIFY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// InstallStep is a single operation of an InstallPlan.
+// Delete and load steps only have the PackageAID, verify steps only have the InstanceAID.
+type InstallStep struct {
+	Action      InstallAction
+	PackageAID  []byte
+	AppletAID   []byte
+	InstanceAID []byte
+	Params      *InstallParams
+}
+
+func (s *InstallStep) String() string {
+	switch s.Action {
+	case ActionDelete, ActionLoad:
+		return fmt.Sprintf("%s package %s", s.Action, hexutils.BytesToHex(s.PackageAID))
+	case ActionInstall:
+		return fmt.Sprintf("%s applet %s as %s", s.Action, hexutils.BytesToHex(s.AppletAID), hexutils.BytesToHex(s.InstanceAID))
+	default:
+		return fmt.Sprintf("%s instance %s", s.Action, hexutils.BytesToHex(s.InstanceAID))
+	}
+}
+
+// InstallPlan lists the steps needed to install the applets.
+// Warnings describe the data that will be lost executing the plan, like the keys of existing instances.
+type InstallPlan struct {
+	Existing []*types.ApplicationEntry
+	Steps    []*InstallStep
+	Warnings []string
+}
+
+// InstallerApplet is an applet instance to install. Nil Params install the instance without parameters.
+type InstallerApplet struct {
+	Name        string
+	AppletAID   []byte
+	InstanceAID []byte
+	Params      *InstallParams
+}
+
+// KeycardInstallerApplet returns the Keycard applet instance described by params.
+func KeycardInstallerApplet(params *KeycardInstallParams) (*InstallerApplet, error) {
+	instanceAID, err := params.InstanceAID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &InstallerApplet{
+		Name:        "keycard",
+		AppletAID:   identifiers.KeycardAID,
+		InstanceAID: instanceAID,
+		Params:      params.InstallParams(),
+	}, nil
+}
+
+// NDEFInstallerApplet returns the NDEF applet instance with the specified NDEF record.
+func NDEFInstallerApplet(ndefRecord []byte) *InstallerApplet {
+	return &InstallerApplet{
+		Name:        "ndef",
+		AppletAID:   identifiers.NdefAID,
+		InstanceAID: identifiers.NdefInstanceAID,
+		Params:      NewInstallParams(ndefRecord),
+	}
+}
+
+// CashInstallerApplet returns the Cash applet instance.
+func CashInstallerApplet(params []byte) *InstallerApplet {
+	return &InstallerApplet{
+		Name:        "cash",
+		AppletAID:   identifiers.CashAID,
+		InstanceAID: identifiers.CashInstanceAID,
+		Params:      NewInstallParams(params),
+	}
+}
+
+// CashInstallerAppletWithPublicData returns the Cash applet instance with the specified public data.
+func CashInstallerAppletWithPublicData(publicData *types.CashPublicData) (*InstallerApplet, error) {
+	data, err := publicData.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return CashInstallerApplet(data), nil
+}
+
+// InstallerOptions configures an Installer.
+type InstallerOptions struct {
+	Applets []*InstallerApplet
+	// SecurityLevel is the security level of the secure channel. The default is C-MAC.
+	SecurityLevel uint8
+	// KeySets are the key sets used to open the secure channel. The default key sets are used if empty.
+	KeySets []*KeySet
+	// KeyVersion is the key version requested when opening the secure channel. The card chooses if 0.
+	KeyVersion uint8
+}
+
+// Installer installs or upgrades the Keycard package and its applets.
+type Installer struct {
+	cs      *CommandSet
+	r       io.ReaderAt
+	size    int64
+	capFile *capfile.CapFile
+	opts    *InstallerOptions
+}
+
+// NewInstaller returns a new Installer loading the CAP file of the specified size read from r.
+func NewInstaller(c types.Channel, r io.ReaderAt, size int64, opts *InstallerOptions) *Installer {
+	cs := NewCommandSet(c)
+	if len(opts.KeySets) > 0 {
+		cs.SetKeySets(opts.KeySets...)
+	}
+
+	cs.SetKeyVersion(opts.KeyVersion)
+
+	return &Installer{
+		cs:   cs,
+		r:    r,
+		size: size,
+		opts: opts,
+	}
+}
+
+// CommandSet returns the CommandSet used by the installer.
+func (i *Installer) CommandSet() *CommandSet {
+	return i.cs
+}
+
+// Plan selects the ISD, opens a secure channel and returns the steps needed to install the applets,
+// without changing anything on the card. It can be used as a dry-run before Execute.
+func (i *Installer) Plan() (*InstallPlan, error) {
+	c, err := capfile.Parse(i.r, i.size)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, applet := range i.opts.Applets {
+		if !containsAID(c.AppletAIDs(), applet.AppletAID) {
+			return nil, fmt.Errorf("%s: %w", applet.Name, ErrAppletNotInPackage)
+		}
+
+		if err := appletParams(applet).Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", applet.Name, err)
+		}
+	}
+
+	i.capFile = c
+
+	if err := i.cs.Select(); err != nil {
+		return nil, err
+	}
+
+	level := i.opts.SecurityLevel
+	if level == 0 {
+		level = SecurityLevelCMAC
+	}
+
+	if err := i.cs.OpenSecureChannelWithSecurityLevel(level); err != nil {
+		return nil, err
+	}
+
+	apps, err := i.cs.GetStatusApplications()
+	if err != nil {
+		return nil, err
+	}
+
+	loadFiles, err := i.cs.GetStatusLoadFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	pkgAID := c.PackageAID()
+	plan := &InstallPlan{
+		Existing: make([]*types.ApplicationEntry, 0),
+		Steps:    make([]*InstallStep, 0),
+		Warnings: make([]string, 0),
+	}
+
+	for _, app := range apps {
+		if warning, ok := packageInstanceWarning(app.AID); ok {
+			plan.Existing = append(plan.Existing, app)
+			plan.Warnings = append(plan.Warnings, warning)
+		}
+	}
+
+	packageLoaded := false
+	for _, f := range loadFiles {
+		if bytes.Equal(f.AID, pkgAID) {
+			packageLoaded = true
+		}
+	}
+
+	if packageLoaded || len(plan.Existing) > 0 {
+		plan.Steps = append(plan.Steps, &InstallStep{Action: ActionDelete, PackageAID: pkgAID})
+	}
+
+	plan.Steps = append(plan.Steps, &InstallStep{Action: ActionLoad, PackageAID: pkgAID})
+
+	for _, applet := range i.opts.Applets {
+		plan.Steps = append(plan.Steps, &InstallStep{
+			Action:      ActionInstall,
+			PackageAID:  pkgAID,
+			AppletAID:   applet.AppletAID,
+			InstanceAID: applet.InstanceAID,
+			Params:      appletParams(applet),
+		})
+	}
+
+	// applets are selected at the end since selecting them deselects the ISD
+	for _, applet := range i.opts.Applets {
+		plan.Steps = append(plan.Steps, &InstallStep{Action: ActionVerify, InstanceAID: applet.InstanceAID})
+	}
+
+	return plan, nil
+}
+
+// Execute executes the steps of a plan returned by Plan. callback can be nil.
+// The errors of the steps are wrapped, so they can be checked with errors.Is and errors.As.
+func (i *Installer) Execute(plan *InstallPlan, callback LoadingCallback) error {
+	for _, w := range plan.Warnings {
+		logger.Warn("installer", "warning", w)
+	}
+
+	for _, step := range plan.Steps {
+		logger.Debug("installer", "step", step.String())
+
+		var err error
+		switch step.Action {
+		case ActionDelete:
+			err = i.cs.DeleteObjectAndRelatedObject(step.PackageAID)
+		case ActionLoad:
+			err = i.cs.LoadPackageFromReader(i.r, i.size, step.PackageAID, callback)
+		case ActionInstall:
+			err = i.cs.InstallForInstallWithParams(step.PackageAID, step.AppletAID, step.InstanceAID, step.Params)
+		case ActionVerify:
+			err = i.cs.SelectAID(step.InstanceAID)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", step.String(), err)
+		}
+	}
+
+	return nil
+}
+
+// Install plans and executes the installation, returning the executed plan.
+func (i *Installer) Install(callback LoadingCallback) (*InstallPlan, error) {
+	plan, err := i.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, i.Execute(plan, callback)
+}
+
+// appletParams returns the install parameters of applet, empty if not set.
+func appletParams(applet *InstallerApplet) *InstallParams {
+	if applet.Params == nil {
+		return NewInstallParams(nil)
+	}
+
+	return applet.Params
+}
+
+// packageInstanceWarning returns the warning shown when deleting an instance of the Keycard package.
+// It returns false if the AID is not an instance of the package.
+func packageInstanceWarning(aid []byte) (string, bool) {
+	if index, err := identifiers.KeycardInstanceIndex(aid); err == nil {
+		return fmt.Sprintf("Keycard instance %d will be deleted, its keys will be lost", index), true
+	}
+
+	switch {
+	case bytes.Equal(aid, identifiers.CashInstanceAID):
+		return "Cash instance will be deleted, its key will be lost", true
+	case bytes.Equal(aid, identifiers.NdefInstanceAID):
+		return "NDEF instance will be deleted", true
+	default:
+		return "", false
+	}
+}
+
+func containsAID(aids [][]byte, aid []byte) bool {
+	for _, a := range aids {
+		if bytes.Equal(a, aid) {
+			return true
+		}
+	}
+
+	return false
+}
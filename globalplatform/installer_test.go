@@ -0,0 +1,147 @@
+package globalplatform
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/status-im/keycard-go/identifiers"
+	"github.com/status-im/keycard-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstaller_Install(t *testing.T) {
+	data := buildTestCapFile(t, 300, identifiers.KeycardAID, identifiers.NdefAID, identifiers.CashAID)
+	card := newFakeCard(DefaultKeySets()[1])
+
+	// an old Keycard instance and the package are on the card
+	card.responses[InsGetStatus] = []*apdu.Response{
+		{Data: hexutils.HexToBytes("E3124F09A000000804000101019F700107C50100"), Sw1: 0x90, Sw2: 0x00, Sw: SwOK},
+		{Data: hexutils.HexToBytes("E30D4F07A00000080400019F700101"), Sw1: 0x90, Sw2: 0x00, Sw: SwOK},
+	}
+
+	keycard, err := KeycardInstallerApplet(&KeycardInstallParams{InstanceIndex: 1})
+	assert.NoError(t, err)
+
+	installer := NewInstaller(card, bytes.NewReader(data), int64(len(data)), &InstallerOptions{
+		Applets: []*InstallerApplet{keycard, NDEFInstallerApplet([]byte{0x01})},
+	})
+
+	plan, err := installer.Plan()
+	assert.NoError(t, err)
+	assert.Len(t, plan.Existing, 1)
+	assert.Equal(t, []string{"Keycard instance 1 will be deleted, its keys will be lost"}, plan.Warnings)
+
+	actions := make([]InstallAction, 0)
+	for _, step := range plan.Steps {
+		actions = append(actions, step.Action)
+	}
+
+	assert.Equal(t, []InstallAction{ActionDelete, ActionLoad, ActionInstall, ActionInstall, ActionVerify, ActionVerify}, actions)
+	assert.Equal(t, "INSTALL applet A000000804000102 as D2760000850101", plan.Steps[3].String())
+
+	// dry-run doesn't change anything
+	for _, cmd := range card.commands {
+		assert.NotContains(t, []uint8{InsDelete, InsLoad, InsInstall}, cmd.Ins)
+	}
+
+	card.commands = nil
+	blocks := 0
+	assert.NoError(t, installer.Execute(plan, func(int, int) { blocks++ }))
+	assert.Equal(t, 2, blocks)
+
+	ins := make([]uint8, 0)
+	for _, cmd := range card.commands {
+		ins = append(ins, cmd.Ins)
+	}
+
+	assert.Equal(t, []uint8{InsDelete, InsInstall, InsLoad, InsLoad, InsInstall, InsInstall, InsSelect, InsSelect}, ins)
+	assert.Equal(t, identifiers.NdefInstanceAID, card.commands[7].Data)
+}
+
+func TestInstaller_PlanFreshCard(t *testing.T) {
+	data := buildTestCapFile(t, 100, identifiers.KeycardAID)
+	card := newFakeCard(DefaultKeySets()[1])
+	card.responses[InsGetStatus] = []*apdu.Response{
+		{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound},
+		{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound},
+	}
+
+	installer := NewInstaller(card, bytes.NewReader(data), int64(len(data)), &InstallerOptions{
+		Applets: []*InstallerApplet{CashInstallerApplet(nil)},
+	})
+
+	_, err := installer.Plan()
+	assert.EqualError(t, err, "cash: applet not found in the CAP file")
+	assert.True(t, errors.Is(err, ErrAppletNotInPackage))
+
+	keycard, err := KeycardInstallerApplet(&KeycardInstallParams{InstanceIndex: 2})
+	assert.NoError(t, err)
+	installer.opts.Applets = []*InstallerApplet{keycard}
+
+	plan, err := installer.Plan()
+	assert.NoError(t, err)
+	assert.Len(t, plan.Warnings, 0)
+	assert.Equal(t, ActionLoad, plan.Steps[0].Action)
+	assert.Len(t, plan.Steps, 3)
+}
+
+func TestInstaller_VerifyFails(t *testing.T) {
+	data := buildTestCapFile(t, 100, identifiers.KeycardAID)
+	card := newFakeCard(DefaultKeySets()[1])
+	card.responses[InsGetStatus] = []*apdu.Response{
+		{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound},
+		{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound},
+	}
+
+	keycard, err := KeycardInstallerApplet(&KeycardInstallParams{InstanceIndex: 1})
+	assert.NoError(t, err)
+	installer := NewInstaller(card, bytes.NewReader(data), int64(len(data)), &InstallerOptions{
+		Applets: []*InstallerApplet{keycard},
+	})
+
+	plan, err := installer.Plan()
+	assert.NoError(t, err)
+
+	card.responses[InsSelect] = []*apdu.Response{
+		{Sw1: 0x6A, Sw2: 0x82, Sw: SwFileNotFound},
+	}
+
+	err = installer.Execute(plan, func(int, int) {})
+	assert.EqualError(t, err, "VERIFY instance A00000080400010101: bad response 6a82: unexpected response")
+
+	var badResponse *apdu.ErrBadResponse
+	assert.True(t, errors.As(err, &badResponse))
+	assert.Equal(t, uint16(SwFileNotFound), badResponse.Sw)
+}
+
+func TestInstaller_NilParamsAndCallback(t *testing.T) {
+	data := buildTestCapFile(t, 300, identifiers.KeycardAID)
+	card := newFakeCard(DefaultKeySets()[1])
+	card.responses[InsGetStatus] = []*apdu.Response{
+		{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound},
+		{Sw1: 0x6A, Sw2: 0x88, Sw: SwReferencedDataNotFound},
+	}
+
+	instanceAID, err := identifiers.KeycardInstanceAID(identifiers.KeycardDefaultInstanceIndex)
+	assert.NoError(t, err)
+	installer := NewInstaller(card, bytes.NewReader(data), int64(len(data)), &InstallerOptions{
+		Applets: []*InstallerApplet{{Name: "keycard", AppletAID: identifiers.KeycardAID, InstanceAID: instanceAID}},
+	})
+
+	plan, err := installer.Plan()
+	assert.NoError(t, err)
+	assert.Equal(t, NewInstallParams(nil), plan.Steps[1].Params)
+	assert.NoError(t, installer.Execute(plan, nil))
+
+	assert.NoError(t, installer.CommandSet().InstallForInstallWithParams(identifiers.PackageAID, identifiers.KeycardAID, instanceAID, nil))
+}
+
+func TestCashInstallerAppletWithPublicData(t *testing.T) {
+	applet, err := CashInstallerAppletWithPublicData(&types.CashPublicData{ChainID: 1, Label: "tip"})
+	assert.NoError(t, err)
+	assert.Equal(t, identifiers.CashInstanceAID, applet.InstanceAID)
+	assert.Equal(t, "C90AA0088001018203746970", hexutils.BytesToHex(applet.Params.Encode()))
+}
@@ -0,0 +1,202 @@
+package globalplatform
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/status-im/keycard-go/globalplatform/crypto"
+	"github.com/status-im/keycard-go/identifiers"
+)
+
+// KeyDiversification is the method used to derive the card keys from master keys.
+type KeyDiversification uint8
+
+const (
+	DiversificationNone KeyDiversification = iota
+	DiversificationVISA2
+	DiversificationEMVCPS
+)
+
+const (
+	keyComponentTypeDES = 0x80
+	keyComponentTypeAES = 0x88
+
+	keyTypeEnc = 0x01
+	keyTypeMac = 0x02
+	keyTypeDek = 0x03
+
+	diversificationDataLength = 10
+)
+
+var (
+	ErrNoValidKeySet              = errors.New("none of the key sets matches the card keys")
+	ErrBadDiversificationData     = errors.New("diversification data must be 10 bytes long")
+	ErrUnsupportedDiversification = errors.New("unsupported key diversification")
+	ErrInvalidKeyVersion          = errors.New("key version must be between 1 and 127")
+	ErrBadKeyCheckValue           = errors.New("key check value returned by the card doesn't match")
+)
+
+// KeySet contains the static ENC, MAC and DEK keys used to open a secure channel.
+// If Diversification is set, the keys are master keys and the card keys are derived from them
+// using the key diversification data returned by INITIALIZE UPDATE.
+// VISA2 and EMV CPS diversification are defined for 3DES keys, so they are meant to be used with SCP02.
+type KeySet struct {
+	// Version is the key version number. 0 matches any key version.
+	Version         uint8
+	Enc             []byte
+	Mac             []byte
+	Dek             []byte
+	Diversification KeyDiversification
+}
+
+// NewKeySet returns a KeySet using the same key for ENC, MAC and DEK.
+func NewKeySet(version uint8, key []byte) *KeySet {
+	return &KeySet{
+		Version: version,
+		Enc:     key,
+		Mac:     key,
+		Dek:     key,
+	}
+}
+
+// DefaultKeySets returns the key sets used when no other key sets are specified,
+// the Keycard development key and the GlobalPlatform default key.
+func DefaultKeySets() []*KeySet {
+	return []*KeySet{
+		NewKeySet(0, identifiers.KeycardDevelopmentKey),
+		NewKeySet(0, identifiers.GlobalPlatformDefaultKey),
+	}
+}
+
+// Diversify returns the card keys derived from the key set using the diversification data.
+// If the key set has no diversification, it's returned as it is.
+func (ks *KeySet) Diversify(divData []byte) (*KeySet, error) {
+	if ks.Diversification == DiversificationNone {
+		return ks, nil
+	}
+
+	if len(divData) != diversificationDataLength {
+		return nil, ErrBadDiversificationData
+	}
+
+	enc, err := diversifyKey(ks.Enc, ks.Diversification, divData, keyTypeEnc)
+	if err != nil {
+		return nil, err
+	}
+
+	mac, err := diversifyKey(ks.Mac, ks.Diversification, divData, keyTypeMac)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := diversifyKey(ks.Dek, ks.Diversification, divData, keyTypeDek)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeySet{
+		Version: ks.Version,
+		Enc:     enc,
+		Mac:     mac,
+		Dek:     dek,
+	}, nil
+}
+
+func (ks *KeySet) matchesVersion(version uint8) bool {
+	return ks.Version == 0 || ks.Version == version
+}
+
+func diversifyKey(key []byte, d KeyDiversification, divData []byte, keyType byte) ([]byte, error) {
+	if len(key) != 16 {
+		return nil, fmt.Errorf("diversification needs 16 bytes keys, got %d", len(key))
+	}
+
+	var id []byte
+	switch d {
+	case DiversificationVISA2:
+		id = make([]byte, 0, 6)
+		id = append(id, divData[0:2]...)
+		id = append(id, divData[4:8]...)
+	case DiversificationEMVCPS:
+		id = divData[4:10]
+	default:
+		return nil, ErrUnsupportedDiversification
+	}
+
+	data := make([]byte, 0, 16)
+	data = append(data, id...)
+	data = append(data, 0xF0, keyType)
+	data = append(data, id...)
+	data = append(data, 0x0F, keyType)
+
+	return crypto.Encrypt3DESECB(key, data)
+}
+
+// putKeyData returns the data of the PUT KEY command loading the key set, encrypted with dek,
+// and the key check values expected in the response.
+// SCP02 cards get 3DES keys while SCP03 cards get AES keys.
+func (ks *KeySet) putKeyData(scpVersion uint8, dek []byte) ([]byte, []byte, error) {
+	if ks.Version < 0x01 || ks.Version > 0x7F {
+		return nil, nil, ErrInvalidKeyVersion
+	}
+
+	data := []byte{ks.Version}
+	expected := []byte{ks.Version}
+
+	for _, key := range [][]byte{ks.Enc, ks.Mac, ks.Dek} {
+		component, kcv, err := encodeKeyComponent(scpVersion, dek, key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data = append(data, component...)
+		expected = append(expected, kcv...)
+	}
+
+	return data, expected, nil
+}
+
+func encodeKeyComponent(scpVersion uint8, dek []byte, key []byte) ([]byte, []byte, error) {
+	var (
+		component []byte
+		encrypted []byte
+		kcv       []byte
+		err       error
+	)
+
+	if scpVersion == SCP03 {
+		if len(key) != 16 && len(key) != 32 {
+			return nil, nil, fmt.Errorf("AES keys must be 16 or 32 bytes, got %d", len(key))
+		}
+
+		if encrypted, err = crypto.EncryptAESCBC(dek, crypto.NullBytes16, key); err != nil {
+			return nil, nil, err
+		}
+
+		if kcv, err = crypto.KeyCheckValueAES(key); err != nil {
+			return nil, nil, err
+		}
+
+		component = []byte{keyComponentTypeAES, byte(len(encrypted) + 1), byte(len(key))}
+	} else {
+		if len(key) != 16 {
+			return nil, nil, fmt.Errorf("DES keys must be 16 bytes, got %d", len(key))
+		}
+
+		if encrypted, err = crypto.Encrypt3DESECB(dek, key); err != nil {
+			return nil, nil, err
+		}
+
+		if kcv, err = crypto.KeyCheckValueDES(key); err != nil {
+			return nil, nil, err
+		}
+
+		component = []byte{keyComponentTypeDES, byte(len(encrypted))}
+	}
+
+	component = append(component, encrypted...)
+	component = append(component, byte(len(kcv)))
+	component = append(component, kcv...)
+
+	return component, kcv, nil
+}
@@ -0,0 +1,59 @@
+package globalplatform
+
+import (
+	"testing"
+
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySet_Diversify(t *testing.T) {
+	key := hexutils.HexToBytes("404142434445464748494a4b4c4d4e4f")
+	divData := hexutils.HexToBytes("00010203040506070809")
+
+	ks := NewKeySet(0x20, key)
+	same, err := ks.Diversify(divData)
+	assert.NoError(t, err)
+	assert.Equal(t, ks, same)
+
+	ks.Diversification = DiversificationVISA2
+	visa2, err := ks.Diversify(divData)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x20), visa2.Version)
+	assert.Equal(t, DiversificationNone, visa2.Diversification)
+	assert.Equal(t, "8510FC973208218CA86888BCEC203635", hexutils.BytesToHex(visa2.Enc))
+	assert.Equal(t, "1068B5FB6F06C78C7A4DFC343B21C78F", hexutils.BytesToHex(visa2.Mac))
+	assert.Equal(t, "2DAEE809C71405FF259C3BE695A1F66E", hexutils.BytesToHex(visa2.Dek))
+
+	ks.Diversification = DiversificationEMVCPS
+	emv, err := ks.Diversify(divData)
+	assert.NoError(t, err)
+	assert.Equal(t, "0EF59FCBF8019B62E62AF6EA20B8BF25", hexutils.BytesToHex(emv.Enc))
+	assert.Equal(t, "3E383EB6F2762B88155F76BDFED05A02", hexutils.BytesToHex(emv.Mac))
+	assert.Equal(t, "64021E43C0C7D264A1C7C6D01E1C8761", hexutils.BytesToHex(emv.Dek))
+
+	_, err = ks.Diversify(divData[:8])
+	assert.Equal(t, ErrBadDiversificationData, err)
+}
+
+func TestKeySet_MatchesVersion(t *testing.T) {
+	assert.True(t, NewKeySet(0, nil).matchesVersion(0x30))
+	assert.True(t, NewKeySet(0x30, nil).matchesVersion(0x30))
+	assert.False(t, NewKeySet(0x31, nil).matchesVersion(0x30))
+}
+
+func TestKeySet_PutKeyDataAES(t *testing.T) {
+	dek := hexutils.HexToBytes("404142434445464748494a4b4c4d4e4f")
+	ks := NewKeySet(0x30, hexutils.HexToBytes("505152535455565758595a5b5c5d5e5f"))
+
+	data, expected, err := ks.putKeyData(SCP03, dek)
+	assert.NoError(t, err)
+
+	component := "8811" + "10" + "C62E5212589B9EAF19AE940D03C3B7C2" + "03" + "F2A8DF"
+	assert.Equal(t, "30"+component+component+component, hexutils.BytesToHex(data))
+	assert.Equal(t, "30F2A8DFF2A8DFF2A8DF", hexutils.BytesToHex(expected))
+
+	ks.Enc = ks.Enc[:8]
+	_, _, err = ks.putKeyData(SCP03, dek)
+	assert.Error(t, err)
+}
@@ -1,49 +1,86 @@
 package globalplatform
 
 import (
-	"archive/zip"
 	"bytes"
-	"io/ioutil"
+	"crypto/sha1"
+	"crypto/sha256"
+	"io"
 	"math"
 	"os"
-	"strings"
 
 	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/capfile"
 )
 
-var internalFiles = []string{
-	"Header", "Directory", "Import", "Applet", "Class",
-	"Method", "StaticField", "Export", "ConstantPool", "RefLocation",
-}
+const (
+	// DefaultLoadBlockSize is the maximum data length of LOAD commands sent with C-MAC (255 - 8 bytes for MAC).
+	DefaultLoadBlockSize = 247
+	// EncryptedLoadBlockSize is the maximum data length of LOAD commands sent with C-MAC and C-DEC,
+	// leaving room for the padding added before encryption.
+	EncryptedLoadBlockSize = 239
+)
+
+// LoadFileHash is the algorithm used to hash the load file data block sent with INSTALL [for load].
+type LoadFileHash uint8
 
-const blockSize = 247 // 255 - 8 bytes for MAC
+const (
+	LoadFileHashNone LoadFileHash = iota
+	LoadFileHashSHA1
+	LoadFileHashSHA256
+)
+
+// Compute returns the hash of the load file data block of c. It returns an empty hash for LoadFileHashNone.
+func (h LoadFileHash) Compute(c *capfile.CapFile) ([]byte, error) {
+	switch h {
+	case LoadFileHashSHA1:
+		return c.LoadFileDataBlockHash(sha1.New)
+	case LoadFileHashSHA256:
+		return c.LoadFileDataBlockHash(sha256.New)
+	default:
+		return []byte{}, nil
+	}
+}
 
 // LoadCommandStream implement a struct that generates multiple Load commands used to load files to smartcards.
+// The data is read from the underlying reader one block at a time.
 type LoadCommandStream struct {
-	data         *bytes.Reader
+	data         io.Reader
+	remaining    int
+	blockSize    int
 	currentIndex uint8
 	currentData  []byte
 	p1           uint8
 	blocksCount  int
+	err          error
 }
 
 // NewLoadCommandStream returns a new LoadCommandStream to load the specified file.
 func NewLoadCommandStream(file *os.File) (*LoadCommandStream, error) {
-	files, err := loadFiles(file)
+	c, err := capfile.Open(file)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := encodeFilesData(files)
-	if err != nil {
-		return nil, err
-	}
+	return NewLoadCommandStreamFromCapFile(c, DefaultLoadBlockSize), nil
+}
+
+// NewLoadCommandStreamFromCapFile returns a new LoadCommandStream to load the specified CAP file,
+// sending at most blockSize bytes with each command.
+func NewLoadCommandStreamFromCapFile(c *capfile.CapFile, blockSize int) *LoadCommandStream {
+	size := c.LoadFileDataBlockSize()
+
+	header := []byte{tagLoadFileDataBlock}
+	header = append(header, encodeLength(size)...)
+
+	total := len(header) + size
 
 	return &LoadCommandStream{
-		data:        bytes.NewReader(data),
+		data:        io.MultiReader(bytes.NewReader(header), c.LoadFileDataBlockReader()),
+		remaining:   total,
+		blockSize:   blockSize,
 		p1:          P1LoadMoreBlocks,
-		blocksCount: int(math.Ceil(float64(len(data)) / float64(blockSize))),
-	}, nil
+		blocksCount: int(math.Ceil(float64(total) / float64(blockSize))),
+	}
 }
 
 // BlocksCount returns the total number of blocks based on data length and blockSize
@@ -52,28 +89,39 @@ func (lcs *LoadCommandStream) BlocksCount() int {
 }
 
 // Next returns initialize the data for the next Load command.
-// TODO:@gravityblast update blockSize when using encrypted data
 func (lcs *LoadCommandStream) Next() bool {
-	if lcs.data.Len() == 0 {
+	if lcs.remaining == 0 {
 		return false
 	}
 
-	buf := make([]byte, blockSize)
-	n, err := lcs.data.Read(buf)
-	if err != nil {
+	n := lcs.blockSize
+	if lcs.remaining < n {
+		n = lcs.remaining
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(lcs.data, buf); err != nil {
+		lcs.err = err
+		lcs.remaining = 0
 		return false
 	}
 
-	lcs.currentData = buf[:n]
+	lcs.currentData = buf
 	lcs.currentIndex++
+	lcs.remaining -= n
 
-	if lcs.data.Len() == 0 {
+	if lcs.remaining == 0 {
 		lcs.p1 = P1LoadLastBlock
 	}
 
 	return true
 }
 
+// Err returns the error that stopped Next, if any.
+func (lcs *LoadCommandStream) Err() error {
+	return lcs.err
+}
+
 // Index returns the command index.
 func (lcs *LoadCommandStream) Index() uint8 {
 	return lcs.currentIndex - 1
@@ -84,57 +132,6 @@ func (lcs *LoadCommandStream) GetCommand() *apdu.Command {
 	return apdu.NewCommand(ClaGp, InsLoad, lcs.p1, lcs.Index(), lcs.currentData)
 }
 
-func loadFiles(f *os.File) (map[string][]byte, error) {
-	fi, err := f.Stat()
-	if err != nil {
-		return nil, err
-	}
-
-	z, err := zip.NewReader(f, fi.Size())
-	if err != nil {
-		return nil, err
-	}
-
-	files := make(map[string][]byte)
-
-	for _, item := range z.File {
-		name := strings.Split(item.FileInfo().Name(), ".")[0]
-		f, err := item.Open()
-		if err != nil {
-			return nil, err
-		}
-
-		data, err := ioutil.ReadAll(f)
-		if err != nil {
-			return nil, err
-		}
-
-		files[name] = data
-	}
-
-	return files, nil
-}
-
-func encodeFilesData(files map[string][]byte) ([]byte, error) {
-	var buf bytes.Buffer
-
-	for _, name := range internalFiles {
-		if data, ok := files[name]; ok {
-			buf.Write(data)
-		}
-	}
-
-	filesData := buf.Bytes()
-	length := encodeLength(len(filesData))
-
-	data := make([]byte, 0)
-	data = append(data, tagLoadFileDataBlock)
-	data = append(data, length...)
-	data = append(data, filesData...)
-
-	return data, nil
-}
-
 func encodeLength(length int) []byte {
 	if length < 0x80 {
 		return []byte{byte(length)}
@@ -0,0 +1,159 @@
+package globalplatform
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"testing"
+
+	"github.com/status-im/keycard-go/capfile"
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/status-im/keycard-go/identifiers"
+	"github.com/stretchr/testify/assert"
+)
+
+// buildTestCapFile returns a CAP file of the Keycard package with a Class component of classSize bytes
+// and the specified applets.
+func buildTestCapFile(t *testing.T, classSize int, appletAIDs ...[]byte) []byte {
+	class := make([]byte, classSize+3)
+	class[0] = 0x06
+	binary.BigEndian.PutUint16(class[1:3], uint16(classSize))
+	for i := 3; i < len(class); i++ {
+		class[i] = byte(i)
+	}
+
+	directory := hexutils.HexToBytes("020021001200210004000000000000000000000000000000000000000000000000000000")
+	directory[len(directory)-2] = byte(len(appletAIDs))
+
+	components := map[string][]byte{
+		"Header":    hexutils.HexToBytes("010012DECAFFED020204010307A000000804000100"),
+		"Directory": directory,
+		"Import":    hexutils.HexToBytes("04000100"),
+		"Class":     class,
+	}
+
+	if len(appletAIDs) > 0 {
+		applet := []byte{byte(len(appletAIDs))}
+		for _, aid := range appletAIDs {
+			applet = append(applet, byte(len(aid)))
+			applet = append(applet, aid...)
+			applet = append(applet, 0x00, 0x10)
+		}
+
+		header := []byte{0x03, 0x00, byte(len(applet))}
+		components["Applet"] = append(header, applet...)
+	}
+
+	buf := new(bytes.Buffer)
+	z := zip.NewWriter(buf)
+	for _, name := range capfile.LoadFileComponents {
+		data, ok := components[name]
+		if !ok {
+			continue
+		}
+
+		f, err := z.Create("im/status/keycard/javacard/" + name + ".cap")
+		assert.NoError(t, err)
+		_, err = f.Write(data)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, z.Close())
+
+	return buf.Bytes()
+}
+
+func TestLoadCommandStream(t *testing.T) {
+	data := buildTestCapFile(t, 600)
+	c, err := capfile.Parse(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	block, err := c.LoadFileDataBlock()
+	assert.NoError(t, err)
+	expected := append([]byte{tagLoadFileDataBlock}, encodeLength(len(block))...)
+	expected = append(expected, block...)
+
+	lcs := NewLoadCommandStreamFromCapFile(c, DefaultLoadBlockSize)
+	assert.Equal(t, 3, lcs.BlocksCount())
+
+	loaded := make([]byte, 0)
+	count := 0
+	for lcs.Next() {
+		cmd := lcs.GetCommand()
+		assert.Equal(t, uint8(count), cmd.P2)
+		assert.LessOrEqual(t, len(cmd.Data), DefaultLoadBlockSize)
+
+		if count < 2 {
+			assert.Equal(t, uint8(P1LoadMoreBlocks), cmd.P1)
+		} else {
+			assert.Equal(t, uint8(P1LoadLastBlock), cmd.P1)
+		}
+
+		loaded = append(loaded, cmd.Data...)
+		count++
+	}
+
+	assert.NoError(t, lcs.Err())
+	assert.Equal(t, 3, count)
+	assert.Equal(t, expected, loaded)
+}
+
+func TestCommandSet_LoadPackageFromReader(t *testing.T) {
+	data := buildTestCapFile(t, 600)
+	c, err := capfile.Parse(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	block, err := c.LoadFileDataBlock()
+	assert.NoError(t, err)
+
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+	assert.NoError(t, cs.OpenSecureChannel())
+
+	err = cs.LoadPackageFromReader(bytes.NewReader(data), int64(len(data)), identifiers.KeycardAID, func(int, int) {})
+	assert.Equal(t, ErrPackageAIDMismatch, err)
+
+	card.commands = nil
+	blocks := make([]int, 0)
+	callback := func(block, total int) {
+		assert.Equal(t, 3, total)
+		blocks = append(blocks, block)
+	}
+
+	err = cs.LoadPackageFromReader(bytes.NewReader(data), int64(len(data)), identifiers.PackageAID, callback)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, blocks)
+
+	// INSTALL [for load] with the SHA-1 hash of the load file data block
+	hash := sha1.Sum(block)
+	installData := append([]byte{0x07}, identifiers.PackageAID...)
+	installData = append(installData, 0x00, 0x14)
+	installData = append(installData, hash[:]...)
+	installData = append(installData, 0x00, 0x00)
+	assert.Equal(t, uint8(InsInstall), card.commands[0].Ins)
+	assert.Equal(t, installData, card.commands[0].Data[:len(installData)])
+
+	assert.Len(t, card.commands, 4)
+	for _, cmd := range card.commands[1:] {
+		assert.Equal(t, uint8(InsLoad), cmd.Ins)
+	}
+}
+
+func TestCommandSet_LoadPackageEncrypted(t *testing.T) {
+	data := buildTestCapFile(t, 600)
+
+	card := newFakeCard(DefaultKeySets()[1])
+	cs := NewCommandSet(card)
+	cs.SetLoadFileHash(LoadFileHashNone)
+	assert.NoError(t, cs.OpenSecureChannelWithSecurityLevel(SecurityLevelCDecCMAC))
+	assert.Equal(t, EncryptedLoadBlockSize, cs.SecureChannel().MaxDataLength())
+
+	card.commands = nil
+	err := cs.LoadPackageFromReader(bytes.NewReader(data), int64(len(data)), identifiers.PackageAID, func(int, int) {})
+	assert.NoError(t, err)
+
+	for _, cmd := range card.commands[1:] {
+		// encrypted data and MAC fit in a short APDU
+		assert.LessOrEqual(t, len(cmd.Data), 255)
+	}
+}
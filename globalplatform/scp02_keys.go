@@ -2,8 +2,9 @@ package globalplatform
 
 // SCP02Keys is a struct that contains encoding and MAC keys used to communicate with smartcards.
 type SCP02Keys struct {
-	enc []byte
-	mac []byte
+	enc  []byte
+	mac  []byte
+	rmac []byte
 }
 
 // Enc returns the enc key data.
@@ -16,6 +17,11 @@ func (k *SCP02Keys) Mac() []byte {
 	return k.mac
 }
 
+// Rmac returns the R-MAC key data. It's only set for session keys.
+func (k *SCP02Keys) Rmac() []byte {
+	return k.rmac
+}
+
 // NewSCP02Keys returns a new SCP02Keys with the specified ENC and MAC keys.
 func NewSCP02Keys(enc, mac []byte) *SCP02Keys {
 	return &SCP02Keys{
@@ -23,3 +29,12 @@ func NewSCP02Keys(enc, mac []byte) *SCP02Keys {
 		mac: mac,
 	}
 }
+
+// NewSCP02SessionKeys returns a new SCP02Keys with the specified session ENC, MAC and R-MAC keys.
+func NewSCP02SessionKeys(enc, mac, rmac []byte) *SCP02Keys {
+	return &SCP02Keys{
+		enc:  enc,
+		mac:  mac,
+		rmac: rmac,
+	}
+}
@@ -3,28 +3,82 @@ package globalplatform
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 
 	"github.com/status-im/keycard-go/apdu"
 	"github.com/status-im/keycard-go/globalplatform/crypto"
 )
 
+var errMissingSessionKey = errors.New("session key needed by the security level is missing")
+
 // SCP02Wrapper is a wrapper for apdu commands inside a global platform secure channel.
+// Commands are always MACed. Depending on the security level, the command data is encrypted
+// and the response MAC is verified.
 type SCP02Wrapper struct {
-	macKey []byte
-	icv    []byte
+	macKey        []byte
+	encKey        []byte
+	rmacKey       []byte
+	icv           []byte
+	ricv          []byte
+	rmacData      []byte
+	securityLevel uint8
 }
 
 // NewSCP02Wrapper returns a new SCP02Wrapper using the specified key for MAC generation.
 func NewSCP02Wrapper(macKey []byte) *SCP02Wrapper {
 	return &SCP02Wrapper{
-		macKey: macKey,
-		icv:    crypto.NullBytes8,
+		macKey:        macKey,
+		icv:           crypto.NullBytes8,
+		ricv:          crypto.NullBytes8,
+		securityLevel: SecurityLevelCMAC,
+	}
+}
+
+// NewSCP02SessionWrapper returns a new SCP02Wrapper using the session keys for MAC generation,
+// command encryption and response MAC verification.
+func NewSCP02SessionWrapper(keys *SCP02Keys) *SCP02Wrapper {
+	w := NewSCP02Wrapper(keys.Mac())
+	w.encKey = keys.Enc()
+	w.rmacKey = keys.Rmac()
+
+	return w
+}
+
+// SecurityLevel returns the current security level.
+func (w *SCP02Wrapper) SecurityLevel() uint8 {
+	return w.securityLevel
+}
+
+// SetSecurityLevel sets the security level applied to the following commands and responses.
+func (w *SCP02Wrapper) SetSecurityLevel(level uint8) error {
+	if !isSecurityLevelSupported(SCP02, level) {
+		return ErrUnsupportedSecurityLevel
+	}
+
+	if (level&securityLevelCDec != 0 && w.encKey == nil) || (level&securityLevelRMAC != 0 && w.rmacKey == nil) {
+		return errMissingSessionKey
 	}
+
+	w.securityLevel = level
+
+	return nil
 }
 
 // Wrap wraps the apdu command adding the MAC to the end of the command.
-// Future implementations will encrypt the message when needed.
+// The MAC is calculated on the plain data, which is then encrypted if the security level requires it.
+// ErrWrappedDataTooLong is returned if the data and the MAC don't fit in a short APDU,
+// in which case the ICV is not changed.
 func (w *SCP02Wrapper) Wrap(cmd *apdu.Command) (*apdu.Command, error) {
+	wrappedLength := len(cmd.Data)
+	if w.securityLevel&securityLevelCDec != 0 && wrappedLength > 0 {
+		// the padding adds 1 to 8 bytes
+		wrappedLength += 8 - wrappedLength%8
+	}
+
+	if wrappedLength+8 > maxWrappedDataLength {
+		return nil, ErrWrappedDataTooLong
+	}
+
 	macData := new(bytes.Buffer)
 
 	cla := cmd.Cla | 0x04
@@ -71,8 +125,20 @@ func (w *SCP02Wrapper) Wrap(cmd *apdu.Command) (*apdu.Command, error) {
 		return nil, err
 	}
 
+	data := cmd.Data
+	if w.securityLevel&securityLevelCDec != 0 && len(data) > 0 {
+		data, err = crypto.Encrypt3DESCBC(w.encKey, crypto.NullBytes8, crypto.AppendDESPadding(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if w.securityLevel&securityLevelRMAC != 0 {
+		w.rmacData = append([]byte{cmd.Cla, cmd.Ins, cmd.P1, cmd.P2, uint8(len(cmd.Data))}, cmd.Data...)
+	}
+
 	newData := make([]byte, 0)
-	newData = append(newData, cmd.Data...)
+	newData = append(newData, data...)
 	newData = append(newData, mac...)
 
 	w.icv = mac
@@ -84,3 +150,42 @@ func (w *SCP02Wrapper) Wrap(cmd *apdu.Command) (*apdu.Command, error) {
 
 	return newCmd, nil
 }
+
+// Unwrap verifies and removes the response MAC if the security level requires it.
+// Responses with an error status word don't carry a MAC and are returned as they are.
+func (w *SCP02Wrapper) Unwrap(resp *apdu.Response) (*apdu.Response, error) {
+	if w.securityLevel&securityLevelRMAC == 0 || !hasResponseMAC(resp) {
+		return resp, nil
+	}
+
+	if len(resp.Data) < 8 {
+		return nil, ErrBadResponseMAC
+	}
+
+	data := resp.Data[:len(resp.Data)-8]
+	rmac := resp.Data[len(resp.Data)-8:]
+
+	macData := make([]byte, 0, len(w.rmacData)+len(data)+3)
+	macData = append(macData, w.rmacData...)
+	macData = append(macData, uint8(len(data)))
+	macData = append(macData, data...)
+	macData = append(macData, resp.Sw1, resp.Sw2)
+
+	mac, err := crypto.MacFull3DES(w.rmacKey, macData, w.ricv)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(mac, rmac) {
+		return nil, ErrBadResponseMAC
+	}
+
+	w.ricv = mac
+
+	return &apdu.Response{
+		Data: data,
+		Sw1:  resp.Sw1,
+		Sw2:  resp.Sw2,
+		Sw:   resp.Sw,
+	}, nil
+}
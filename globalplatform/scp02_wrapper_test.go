@@ -41,3 +41,75 @@ func TestSCP02Wrapper_Wrap(t *testing.T) {
 	expected = "84 F2 80 02 0A 4F 00 30 F1 49 20 9E 17 B3 97 00"
 	assert.Equal(t, expected, hexutils.BytesToHexWithSpaces(raw))
 }
+
+func TestSCP02Wrapper_WrapEncrypted(t *testing.T) {
+	keys := NewSCP02SessionKeys(
+		hexutils.HexToBytes("85E72AAF47874218A202BF5EF891DD21"),
+		hexutils.HexToBytes("2983BA77D709C2DAA1E6000ABCCAC951"),
+		hexutils.HexToBytes("5b02e75ad63190aece0622936f11abab"),
+	)
+	w := NewSCP02SessionWrapper(keys)
+
+	data := hexutils.HexToBytes("1d4de92eaf7a2c9f")
+	cmd := apdu.NewCommand(uint8(0x80), uint8(0x82), uint8(0x01), uint8(0x00), data)
+	_, err := w.Wrap(cmd)
+	assert.NoError(t, err)
+	assert.NoError(t, w.SetSecurityLevel(SecurityLevelCDecCMACRMAC))
+
+	// the MAC is the same as the unencrypted command since it's calculated on the plain data
+	cmd = apdu.NewCommand(uint8(0x80), uint8(0xF2), uint8(0x80), uint8(0x02), hexutils.HexToBytes("4F00"))
+	cmd.SetLe(0x00)
+	wrappedCmd, err := w.Wrap(cmd)
+	assert.NoError(t, err)
+	raw, err := wrappedCmd.Serialize()
+	assert.NoError(t, err)
+
+	expected := "84 F2 80 02 10 84 AD E3 A6 F2 F0 17 67 30 F1 49 20 9E 17 B3 97 00"
+	assert.Equal(t, expected, hexutils.BytesToHexWithSpaces(raw))
+
+	resp, err := apdu.ParseResponse(hexutils.HexToBytes("E3054F03A0000053C08ADF8A0705289000"))
+	assert.NoError(t, err)
+	unwrapped, err := w.Unwrap(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "E3054F03A00000", hexutils.BytesToHex(unwrapped.Data))
+	assert.Equal(t, "53C08ADF8A070528", hexutils.BytesToHex(w.ricv))
+
+	// same response doesn't verify with the new ICV
+	_, err = w.Unwrap(resp)
+	assert.Equal(t, ErrBadResponseMAC, err)
+}
+
+func TestSCP02Wrapper_WrapMaxLength(t *testing.T) {
+	keys := NewSCP02SessionKeys(
+		hexutils.HexToBytes("85E72AAF47874218A202BF5EF891DD21"),
+		hexutils.HexToBytes("2983BA77D709C2DAA1E6000ABCCAC951"),
+		hexutils.HexToBytes("5b02e75ad63190aece0622936f11abab"),
+	)
+	w := NewSCP02SessionWrapper(keys)
+
+	wrappedCmd, err := w.Wrap(apdu.NewCommand(0x80, InsLoad, P1LoadMoreBlocks, 0x00, make([]byte, 247)))
+	assert.NoError(t, err)
+	assert.Len(t, wrappedCmd.Data, 255)
+	icv := w.icv
+
+	_, err = w.Wrap(apdu.NewCommand(0x80, InsLoad, P1LoadMoreBlocks, 0x00, make([]byte, 248)))
+	assert.Equal(t, ErrWrappedDataTooLong, err)
+	assert.Equal(t, icv, w.icv)
+
+	// the padding of the encrypted data is counted
+	assert.NoError(t, w.SetSecurityLevel(SecurityLevelCDecCMAC))
+	_, err = w.Wrap(apdu.NewCommand(0x80, InsLoad, P1LoadMoreBlocks, 0x00, make([]byte, 240)))
+	assert.Equal(t, ErrWrappedDataTooLong, err)
+	assert.Equal(t, icv, w.icv)
+
+	wrappedCmd, err = w.Wrap(apdu.NewCommand(0x80, InsLoad, P1LoadMoreBlocks, 0x00, make([]byte, 239)))
+	assert.NoError(t, err)
+	assert.Len(t, wrappedCmd.Data, 248)
+}
+
+func TestSCP02Wrapper_SetSecurityLevel(t *testing.T) {
+	w := NewSCP02Wrapper(hexutils.HexToBytes("2983BA77D709C2DAA1E6000ABCCAC951"))
+	assert.Equal(t, ErrUnsupportedSecurityLevel, w.SetSecurityLevel(SecurityLevelCDecREncCMACRMAC))
+	assert.Equal(t, errMissingSessionKey, w.SetSecurityLevel(SecurityLevelCDecCMAC))
+	assert.NoError(t, w.SetSecurityLevel(SecurityLevelCMAC))
+}
@@ -0,0 +1,47 @@
+package globalplatform
+
+// SCP03Keys is a struct that contains the static AES ENC and MAC keys used to open an SCP03 secure channel.
+type SCP03Keys struct {
+	enc []byte
+	mac []byte
+}
+
+// Enc returns the enc key data.
+func (k *SCP03Keys) Enc() []byte {
+	return k.enc
+}
+
+// Mac returns the MAC key data.
+func (k *SCP03Keys) Mac() []byte {
+	return k.mac
+}
+
+// NewSCP03Keys returns a new SCP03Keys with the specified ENC and MAC keys.
+func NewSCP03Keys(enc, mac []byte) *SCP03Keys {
+	return &SCP03Keys{
+		enc: enc,
+		mac: mac,
+	}
+}
+
+// SCP03SessionKeys contains the session keys derived when opening an SCP03 secure channel.
+type SCP03SessionKeys struct {
+	enc  []byte
+	mac  []byte
+	rmac []byte
+}
+
+// Enc returns the S-ENC key data.
+func (k *SCP03SessionKeys) Enc() []byte {
+	return k.enc
+}
+
+// Mac returns the S-MAC key data.
+func (k *SCP03SessionKeys) Mac() []byte {
+	return k.mac
+}
+
+// Rmac returns the S-RMAC key data.
+func (k *SCP03SessionKeys) Rmac() []byte {
+	return k.rmac
+}
@@ -0,0 +1,177 @@
+package globalplatform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/globalplatform/crypto"
+)
+
+// maxWrappedDataLength is the maximum length of the data of a wrapped command, including the MAC.
+const maxWrappedDataLength = 0xFF
+
+var (
+	ErrUnsupportedSecurityLevel = errors.New("unsupported security level")
+	ErrBadResponseMAC           = errors.New("bad response MAC")
+	ErrWrappedDataTooLong       = errors.New("command data too long to be wrapped")
+)
+
+// SCP03Wrapper is a wrapper for apdu commands inside a SCP03 global platform secure channel.
+// Commands are always MACed. Depending on the security level, the command data is encrypted
+// and the response MAC is verified and its data decrypted.
+type SCP03Wrapper struct {
+	keys          *SCP03SessionKeys
+	securityLevel uint8
+	chainingValue []byte
+	counter       uint32
+}
+
+// NewSCP03Wrapper returns a new SCP03Wrapper using the specified session keys.
+// The security level is initially set to C-MAC, as needed to send the EXTERNAL AUTHENTICATE command.
+func NewSCP03Wrapper(keys *SCP03SessionKeys) *SCP03Wrapper {
+	return &SCP03Wrapper{
+		keys:          keys,
+		securityLevel: SecurityLevelCMAC,
+		chainingValue: crypto.NullBytes16,
+	}
+}
+
+// SecurityLevel returns the current security level.
+func (w *SCP03Wrapper) SecurityLevel() uint8 {
+	return w.securityLevel
+}
+
+// SetSecurityLevel sets the security level applied to the following commands and responses.
+func (w *SCP03Wrapper) SetSecurityLevel(level uint8) error {
+	if !isSecurityLevelSupported(SCP03, level) {
+		return ErrUnsupportedSecurityLevel
+	}
+
+	w.securityLevel = level
+
+	return nil
+}
+
+// Wrap encrypts the command data if needed and appends the MAC to the end of the command.
+// ErrWrappedDataTooLong is returned if the data and the MAC don't fit in a short APDU,
+// in which case the state of the channel is not changed.
+func (w *SCP03Wrapper) Wrap(cmd *apdu.Command) (*apdu.Command, error) {
+	data := cmd.Data
+	counter := w.counter
+
+	if w.securityLevel&securityLevelCDec != 0 {
+		// the counter is incremented for each command, even without data to encrypt
+		counter++
+
+		if len(data) > 0 {
+			icv, err := crypto.EncryptAESBlock(w.keys.Enc(), counterBlock(0x00, counter))
+			if err != nil {
+				return nil, err
+			}
+
+			data, err = crypto.EncryptAESCBC(w.keys.Enc(), icv, crypto.AppendAESPadding(data))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(data)+8 > maxWrappedDataLength {
+		return nil, ErrWrappedDataTooLong
+	}
+
+	cla := cmd.Cla | 0x04
+
+	macData := new(bytes.Buffer)
+	macData.Write(w.chainingValue)
+	macData.Write([]byte{cla, cmd.Ins, cmd.P1, cmd.P2, uint8(len(data) + 8)})
+	macData.Write(data)
+
+	mac, err := crypto.CMAC(w.keys.Mac(), macData.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	w.chainingValue = mac
+	w.counter = counter
+
+	newData := make([]byte, 0, len(data)+8)
+	newData = append(newData, data...)
+	newData = append(newData, mac[:8]...)
+
+	newCmd := apdu.NewCommand(cla, cmd.Ins, cmd.P1, cmd.P2, newData)
+	if ok, le := cmd.Le(); ok {
+		newCmd.SetLe(le)
+	}
+
+	return newCmd, nil
+}
+
+// Unwrap verifies the response MAC and decrypts the response data if needed.
+// Responses with an error status word don't carry a MAC and are returned as they are.
+func (w *SCP03Wrapper) Unwrap(resp *apdu.Response) (*apdu.Response, error) {
+	if w.securityLevel&securityLevelRMAC == 0 || !hasResponseMAC(resp) {
+		return resp, nil
+	}
+
+	if len(resp.Data) < 8 {
+		return nil, ErrBadResponseMAC
+	}
+
+	data := resp.Data[:len(resp.Data)-8]
+	rmac := resp.Data[len(resp.Data)-8:]
+
+	macData := new(bytes.Buffer)
+	macData.Write(w.chainingValue)
+	macData.Write(data)
+	macData.Write([]byte{resp.Sw1, resp.Sw2})
+
+	mac, err := crypto.CMAC(w.keys.Rmac(), macData.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(mac[:8], rmac) {
+		return nil, ErrBadResponseMAC
+	}
+
+	if w.securityLevel&securityLevelREnc != 0 && len(data) > 0 {
+		icv, err := crypto.EncryptAESBlock(w.keys.Enc(), counterBlock(0x80, w.counter))
+		if err != nil {
+			return nil, err
+		}
+
+		data, err = crypto.DecryptAESCBC(w.keys.Enc(), icv, data)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err = crypto.RemovePadding(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &apdu.Response{
+		Data: data,
+		Sw1:  resp.Sw1,
+		Sw2:  resp.Sw2,
+		Sw:   resp.Sw,
+	}, nil
+}
+
+func counterBlock(first byte, counter uint32) []byte {
+	block := make([]byte, 16)
+	block[0] = first
+	binary.BigEndian.PutUint32(block[12:], counter)
+
+	return block
+}
+
+// hasResponseMAC returns true if the card is expected to add a MAC to the response,
+// which is the case for successful responses and warnings.
+func hasResponseMAC(resp *apdu.Response) bool {
+	return resp.Sw == SwOK || resp.Sw1 == 0x62 || resp.Sw1 == 0x63
+}
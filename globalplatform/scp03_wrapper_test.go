@@ -0,0 +1,109 @@
+package globalplatform
+
+import (
+	"testing"
+
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/globalplatform/crypto"
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// The expected wrapped commands and the card responses have been computed with the AES and CMAC
+// primitives of pyca/cryptography, following GP Amendment D 6.2 and 6.3.
+
+func newTestSCP03SessionKeys() *SCP03SessionKeys {
+	return &SCP03SessionKeys{
+		enc:  hexutils.HexToBytes("00112233445566778899AABBCCDDEEFF"),
+		mac:  hexutils.HexToBytes("0F0E0D0C0B0A09080706050403020100"),
+		rmac: hexutils.HexToBytes("F0E0D0C0B0A090807060504030201000"),
+	}
+}
+
+func TestSCP03Wrapper_Wrap(t *testing.T) {
+	w := NewSCP03Wrapper(newTestSCP03SessionKeys())
+	assert.Equal(t, crypto.NullBytes16, w.chainingValue)
+
+	data := hexutils.HexToBytes("C28A6A8B99435E37")
+	cmd := apdu.NewCommand(0x80, InsExternalAuthenticate, SecurityLevelCDecREncCMACRMAC, 0x00, data)
+	wrappedCmd, err := w.Wrap(cmd)
+	assert.NoError(t, err)
+	raw, err := wrappedCmd.Serialize()
+	assert.NoError(t, err)
+
+	expected := "84 82 33 00 10 C2 8A 6A 8B 99 43 5E 37 8A E3 EE C5 CE 1B AB 5C"
+	assert.Equal(t, expected, hexutils.BytesToHexWithSpaces(raw))
+	assert.Equal(t, "8AE3EEC5CE1BAB5C6EF34AC87F902F52", hexutils.BytesToHex(w.chainingValue))
+	assert.Equal(t, uint32(0), w.counter)
+
+	assert.NoError(t, w.SetSecurityLevel(SecurityLevelCDecREncCMACRMAC))
+
+	cmd = apdu.NewCommand(0x80, InsGetStatus, 0x80, 0x02, hexutils.HexToBytes("4F00"))
+	cmd.SetLe(0)
+	wrappedCmd, err = w.Wrap(cmd)
+	assert.NoError(t, err)
+	raw, err = wrappedCmd.Serialize()
+	assert.NoError(t, err)
+
+	expected = "84 F2 80 02 18 27 45 9A C3 94 AF 91 6F D5 30 C9 79 E2 C6 F3 15 78 8A 6B 58 B8 38 41 F4 00"
+	assert.Equal(t, expected, hexutils.BytesToHexWithSpaces(raw))
+	assert.Equal(t, uint32(1), w.counter)
+
+	resp, err := apdu.ParseResponse(hexutils.HexToBytes("CCA8AB451E35BD55522BD3E6F50B1485D01A85607356F5779000"))
+	assert.NoError(t, err)
+	unwrapped, err := w.Unwrap(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "E3054F03A00000", hexutils.BytesToHex(unwrapped.Data))
+	assert.Equal(t, uint16(SwOK), unwrapped.Sw)
+}
+
+func TestSCP03Wrapper_WrapMaxLength(t *testing.T) {
+	w := NewSCP03Wrapper(newTestSCP03SessionKeys())
+
+	data := make([]byte, 247)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	wrappedCmd, err := w.Wrap(apdu.NewCommand(0x80, InsLoad, P1LoadMoreBlocks, 0x00, data))
+	assert.NoError(t, err)
+	assert.Len(t, wrappedCmd.Data, 255)
+	assert.Equal(t, "9768C1136F38696AF153C1D5CE01C9BF", hexutils.BytesToHex(w.chainingValue))
+
+	_, err = w.Wrap(apdu.NewCommand(0x80, InsLoad, P1LoadMoreBlocks, 0x00, make([]byte, 248)))
+	assert.Equal(t, ErrWrappedDataTooLong, err)
+	assert.Equal(t, "9768C1136F38696AF153C1D5CE01C9BF", hexutils.BytesToHex(w.chainingValue))
+
+	// the padding of the encrypted data is counted
+	assert.NoError(t, w.SetSecurityLevel(SecurityLevelCDecCMAC))
+	_, err = w.Wrap(apdu.NewCommand(0x80, InsLoad, P1LoadMoreBlocks, 0x00, make([]byte, 240)))
+	assert.Equal(t, ErrWrappedDataTooLong, err)
+	assert.Equal(t, uint32(0), w.counter)
+
+	_, err = w.Wrap(apdu.NewCommand(0x80, InsLoad, P1LoadMoreBlocks, 0x00, make([]byte, 239)))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), w.counter)
+}
+
+func TestSCP03Wrapper_UnwrapBadMAC(t *testing.T) {
+	w := NewSCP03Wrapper(newTestSCP03SessionKeys())
+	assert.NoError(t, w.SetSecurityLevel(SecurityLevelCMACRMAC))
+
+	resp, err := apdu.ParseResponse(hexutils.HexToBytes("AABB00000000000000009000"))
+	assert.NoError(t, err)
+	_, err = w.Unwrap(resp)
+	assert.Equal(t, ErrBadResponseMAC, err)
+
+	// errors are not MACed
+	resp, err = apdu.ParseResponse(hexutils.HexToBytes("6A88"))
+	assert.NoError(t, err)
+	unwrapped, err := w.Unwrap(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, resp, unwrapped)
+}
+
+func TestSCP03Wrapper_SetSecurityLevel(t *testing.T) {
+	w := NewSCP03Wrapper(newTestSCP03SessionKeys())
+	assert.Equal(t, ErrUnsupportedSecurityLevel, w.SetSecurityLevel(0x02))
+	assert.Equal(t, uint8(SecurityLevelCMAC), w.securityLevel)
+}
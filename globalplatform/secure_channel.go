@@ -6,23 +6,54 @@ import (
 	"github.com/status-im/keycard-go/types"
 )
 
-// SecureChannel wraps another channel and sends wrapped commands using SCP02Wrapper.
+// Wrapper wraps commands and unwraps responses exchanged inside a secure channel.
+type Wrapper interface {
+	Wrap(cmd *apdu.Command) (*apdu.Command, error)
+	Unwrap(resp *apdu.Response) (*apdu.Response, error)
+	SetSecurityLevel(level uint8) error
+	SecurityLevel() uint8
+}
+
+// SecureChannel wraps another channel and sends wrapped commands using an SCP02Wrapper or SCP03Wrapper,
+// depending on the session SCP version.
 type SecureChannel struct {
 	session *Session
 	c       types.Channel
-	w       *SCP02Wrapper
+	w       Wrapper
 }
 
 // NewSecureChannel returns a new SecureChannel based on a session and wrapping a Channel c.
 func NewSecureChannel(session *Session, c types.Channel) *SecureChannel {
+	var w Wrapper
+	if session.SCPVersion() == SCP03 {
+		w = NewSCP03Wrapper(session.SCP03Keys())
+	} else {
+		w = NewSCP02SessionWrapper(session.Keys())
+	}
+
 	return &SecureChannel{
 		session: session,
 		c:       c,
-		w:       NewSCP02Wrapper(session.Keys().Mac()),
+		w:       w,
 	}
 }
 
-// Send sends wrapped commands to the inner channel.
+// MaxDataLength returns the maximum length of the plain data of a command,
+// taking into account the MAC and, if the command data is encrypted, the padding.
+func (c *SecureChannel) MaxDataLength() int {
+	if c.w.SecurityLevel()&securityLevelCDec != 0 {
+		return EncryptedLoadBlockSize
+	}
+
+	return DefaultLoadBlockSize
+}
+
+// SetSecurityLevel sets the security level used for the commands sent after EXTERNAL AUTHENTICATE.
+func (c *SecureChannel) SetSecurityLevel(level uint8) error {
+	return c.w.SetSecurityLevel(level)
+}
+
+// Send sends wrapped commands to the inner channel and returns the unwrapped response.
 func (c *SecureChannel) Send(cmd *apdu.Command) (*apdu.Response, error) {
 	rawCmd, err := cmd.Serialize()
 	if err != nil {
@@ -35,5 +66,23 @@ func (c *SecureChannel) Send(cmd *apdu.Command) (*apdu.Response, error) {
 		return nil, err
 	}
 
-	return c.c.Send(wrappedCmd)
+	resp, err := c.c.Send(wrappedCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.w.Unwrap(resp)
+}
+
+func isSecurityLevelSupported(scpVersion uint8, level uint8) bool {
+	switch level {
+	case SecurityLevelCMAC:
+		return true
+	case SecurityLevelCDecCMAC, SecurityLevelCMACRMAC, SecurityLevelCDecCMACRMAC:
+		return scpVersion == SCP02 || scpVersion == SCP03
+	case SecurityLevelCDecREncCMACRMAC:
+		return scpVersion == SCP03
+	default:
+		return false
+	}
 }
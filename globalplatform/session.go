@@ -1,6 +1,7 @@
 package globalplatform
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 
@@ -8,25 +9,57 @@ import (
 	"github.com/status-im/keycard-go/globalplatform/crypto"
 )
 
-const supportedSCPVersion = 2
+// Secure Channel Protocol versions.
+const (
+	SCP02 = 0x02
+	SCP03 = 0x03
+)
+
+const supportedSCPVersion = SCP02
 
 // Session is a struct containing the keys and challenges used in the current communication with a card.
 type Session struct {
-	keys          *SCP02Keys
-	cardChallenge []byte
-	hostChallenge []byte
+	scpVersion      uint8
+	divData         []byte
+	keyVersion      uint8
+	sequenceCounter []byte
+	keys            *SCP02Keys
+	scp03Keys       *SCP03SessionKeys
+	cardChallenge   []byte
+	hostChallenge   []byte
 }
 
 var errBadCryptogram = errors.New("bad card cryptogram")
 
-// NewSession returns a new session after validating the cryptogram received from the card.
-func NewSession(cardKeys *SCP02Keys, resp *apdu.Response, hostChallenge []byte) (*Session, error) {
+// SCPVersion returns the SCP version of an INITIALIZE UPDATE response.
+func SCPVersion(resp *apdu.Response) (uint8, error) {
+	if err := checkInitializeUpdateResponse(resp); err != nil {
+		return 0, err
+	}
+
+	if len(resp.Data) < 12 {
+		return 0, apdu.NewErrBadResponse(resp.Sw, fmt.Sprintf("bad data length, expected at least 12, got %d", len(resp.Data)))
+	}
+
+	return resp.Data[11], nil
+}
+
+func checkInitializeUpdateResponse(resp *apdu.Response) error {
 	if resp.Sw == SwSecurityConditionNotSatisfied {
-		return nil, apdu.NewErrBadResponse(resp.Sw, "security condition not satisfied")
+		return apdu.NewErrBadResponse(resp.Sw, "security condition not satisfied")
 	}
 
 	if resp.Sw == SwAuthenticationMethodBlocked {
-		return nil, apdu.NewErrBadResponse(resp.Sw, "authentication method blocked")
+		return apdu.NewErrBadResponse(resp.Sw, "authentication method blocked")
+	}
+
+	return nil
+}
+
+// NewSession returns a new SCP02 session after validating the cryptogram received from the card.
+func NewSession(cardKeys *SCP02Keys, resp *apdu.Response, hostChallenge []byte) (*Session, error) {
+	if err := checkInitializeUpdateResponse(resp); err != nil {
+		return nil, err
 	}
 
 	if len(resp.Data) != 28 {
@@ -47,12 +80,17 @@ func NewSession(cardKeys *SCP02Keys, resp *apdu.Response, hostChallenge []byte)
 		return nil, err
 	}
 
-	sessionMacKey, err := crypto.DeriveKey(cardKeys.Enc(), seq, crypto.DerivationPurposeMac)
+	sessionMacKey, err := crypto.DeriveKey(cardKeys.Mac(), seq, crypto.DerivationPurposeMac)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionRmacKey, err := crypto.DeriveKey(cardKeys.Mac(), seq, crypto.DerivationPurposeRMac)
 	if err != nil {
 		return nil, err
 	}
 
-	sessionKeys := NewSCP02Keys(sessionEncKey, sessionMacKey)
+	sessionKeys := NewSCP02SessionKeys(sessionEncKey, sessionMacKey, sessionRmacKey)
 	verified, err := crypto.VerifyCryptogram(sessionKeys.Enc(), hostChallenge, cardChallenge, cardCryptogram)
 	if err != nil {
 		return nil, err
@@ -63,7 +101,82 @@ func NewSession(cardKeys *SCP02Keys, resp *apdu.Response, hostChallenge []byte)
 	}
 
 	s := &Session{
-		keys:          sessionKeys,
+		scpVersion:      SCP02,
+		divData:         resp.Data[0:10],
+		keyVersion:      resp.Data[10],
+		sequenceCounter: seq,
+		keys:            sessionKeys,
+		cardChallenge:   cardChallenge,
+		hostChallenge:   hostChallenge,
+	}
+
+	return s, nil
+}
+
+// NewSCP03Session returns a new SCP03 session after validating the cryptogram received from the card.
+func NewSCP03Session(cardKeys *SCP03Keys, resp *apdu.Response, hostChallenge []byte) (*Session, error) {
+	if err := checkInitializeUpdateResponse(resp); err != nil {
+		return nil, err
+	}
+
+	// the sequence counter is only returned when the card uses pseudo-random challenges
+	if len(resp.Data) != 29 && len(resp.Data) != 32 {
+		return nil, apdu.NewErrBadResponse(resp.Sw, fmt.Sprintf("bad data length, expected 29 or 32, got %d", len(resp.Data)))
+	}
+
+	scpMajorVersion := resp.Data[11]
+	if scpMajorVersion != SCP03 {
+		return nil, fmt.Errorf("scp version %d not supported", scpMajorVersion)
+	}
+
+	cardChallenge := resp.Data[13:21]
+	cardCryptogram := resp.Data[21:29]
+
+	context := make([]byte, 0, 16)
+	context = append(context, hostChallenge...)
+	context = append(context, cardChallenge...)
+
+	keyBits := len(cardKeys.Enc()) * 8
+	sessionEncKey, err := crypto.DeriveKeySCP03(cardKeys.Enc(), crypto.DerivationConstantSEnc, context, keyBits)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBits = len(cardKeys.Mac()) * 8
+	sessionMacKey, err := crypto.DeriveKeySCP03(cardKeys.Mac(), crypto.DerivationConstantSMac, context, keyBits)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionRmacKey, err := crypto.DeriveKeySCP03(cardKeys.Mac(), crypto.DerivationConstantSRMac, context, keyBits)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := crypto.DeriveKeySCP03(sessionMacKey, crypto.DerivationConstantCardCryptogram, context, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(expected, cardCryptogram) {
+		return nil, errBadCryptogram
+	}
+
+	var seq []byte
+	if len(resp.Data) == 32 {
+		seq = resp.Data[29:32]
+	}
+
+	s := &Session{
+		scpVersion:      SCP03,
+		divData:         resp.Data[0:10],
+		keyVersion:      resp.Data[10],
+		sequenceCounter: seq,
+		scp03Keys: &SCP03SessionKeys{
+			enc:  sessionEncKey,
+			mac:  sessionMacKey,
+			rmac: sessionRmacKey,
+		},
 		cardChallenge: cardChallenge,
 		hostChallenge: hostChallenge,
 	}
@@ -71,11 +184,36 @@ func NewSession(cardKeys *SCP02Keys, resp *apdu.Response, hostChallenge []byte)
 	return s, nil
 }
 
-// Keys return the current SCP02Keys.
+// SCPVersion returns the version of the secure channel protocol used by the session.
+func (s *Session) SCPVersion() uint8 {
+	return s.scpVersion
+}
+
+// DiversificationData returns the key diversification data returned by the card.
+func (s *Session) DiversificationData() []byte {
+	return s.divData
+}
+
+// KeyVersion returns the version of the keys used by the card for the session.
+func (s *Session) KeyVersion() uint8 {
+	return s.keyVersion
+}
+
+// SequenceCounter returns the sequence counter of the card. It's nil for SCP03 cards using random challenges.
+func (s *Session) SequenceCounter() []byte {
+	return s.sequenceCounter
+}
+
+// Keys return the current SCP02Keys. It returns nil for SCP03 sessions.
 func (s *Session) Keys() *SCP02Keys {
 	return s.keys
 }
 
+// SCP03Keys returns the current SCP03 session keys. It returns nil for SCP02 sessions.
+func (s *Session) SCP03Keys() *SCP03SessionKeys {
+	return s.scp03Keys
+}
+
 // CardChallenge returns the current card challenge.
 func (s *Session) CardChallenge() []byte {
 	return s.cardChallenge
@@ -85,3 +223,16 @@ func (s *Session) CardChallenge() []byte {
 func (s *Session) HostChallenge() []byte {
 	return s.hostChallenge
 }
+
+// HostCryptogram returns the cryptogram sent to the card with the EXTERNAL AUTHENTICATE command.
+func (s *Session) HostCryptogram() ([]byte, error) {
+	if s.scpVersion == SCP03 {
+		context := make([]byte, 0, 16)
+		context = append(context, s.hostChallenge...)
+		context = append(context, s.cardChallenge...)
+
+		return crypto.DeriveKeySCP03(s.scp03Keys.Mac(), crypto.DerivationConstantHostCryptogram, context, 64)
+	}
+
+	return calculateHostCryptogram(s.keys.Enc(), s.cardChallenge, s.hostChallenge)
+}
@@ -17,8 +17,11 @@ func TestNewSession(t *testing.T) {
 	assert.NoError(t, err)
 
 	hostChallenge := hexutils.HexToBytes("f0467f908e5ca23f")
-	_, err = NewSession(keys, resp, hostChallenge)
+	s, err := NewSession(keys, resp, hostChallenge)
 	assert.NoError(t, err)
+	assert.Equal(t, "00000265018303953662", hexutils.BytesToHex(s.DiversificationData()))
+	assert.Equal(t, uint8(0x20), s.KeyVersion())
+	assert.Equal(t, "000D", hexutils.BytesToHex(s.SequenceCounter()))
 }
 
 func TestNewSession_BadResponse(t *testing.T) {
@@ -41,3 +44,36 @@ func TestNewSession_BadResponse(t *testing.T) {
 	_, err = NewSession(&SCP02Keys{}, resp, []byte{})
 	assert.Error(t, err)
 }
+
+func TestNewSCP03Session(t *testing.T) {
+	key := hexutils.HexToBytes("404142434445464748494a4b4c4d4e4f")
+	keys := NewSCP03Keys(key, key)
+
+	raw := hexutils.HexToBytes("000102030405060708093003701011121314151617A330F60B35761CBF9000")
+	resp, err := apdu.ParseResponse(raw)
+	assert.NoError(t, err)
+
+	version, err := SCPVersion(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(SCP03), version)
+
+	hostChallenge := hexutils.HexToBytes("0001020304050607")
+	s, err := NewSCP03Session(keys, resp, hostChallenge)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(SCP03), s.SCPVersion())
+	assert.Equal(t, uint8(0x30), s.KeyVersion())
+	assert.Nil(t, s.SequenceCounter())
+	assert.Equal(t, "1011121314151617", hexutils.BytesToHex(s.CardChallenge()))
+	assert.Equal(t, "8AAC6B39A7C426423400D8B5D9C0ADC3", hexutils.BytesToHex(s.SCP03Keys().Enc()))
+	assert.Equal(t, "A2E96754F593E7E80E695BEC50A24283", hexutils.BytesToHex(s.SCP03Keys().Mac()))
+	assert.Equal(t, "983882695EE1AC61743415AB536CE1BE", hexutils.BytesToHex(s.SCP03Keys().Rmac()))
+
+	hostCryptogram, err := s.HostCryptogram()
+	assert.NoError(t, err)
+	assert.Equal(t, "C28A6A8B99435E37", hexutils.BytesToHex(hostCryptogram))
+
+	// wrong keys
+	wrongKey := hexutils.HexToBytes("505152535455565758595a5b5c5d5e5f")
+	_, err = NewSCP03Session(NewSCP03Keys(wrongKey, wrongKey), resp, hostChallenge)
+	assert.Equal(t, errBadCryptogram, err)
+}
@@ -3,7 +3,9 @@ module github.com/status-im/keycard-go
 go 1.17
 
 require (
+	github.com/ebfe/scard v0.0.0-20230420082256-7db3f9b7c8a7
 	github.com/ethereum/go-ethereum v1.10.26
+	github.com/google/uuid v1.2.0
 	github.com/stretchr/testify v1.7.2
 	golang.org/x/crypto v0.1.0
 	golang.org/x/text v0.4.0
@@ -12,9 +14,11 @@ require (
 require (
 	github.com/btcsuite/btcd/btcec/v2 v2.2.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/deckarep/golang-set v1.8.0 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1 // indirect
 	github.com/go-stack/stack v1.8.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/rjeczalik/notify v0.9.1 // indirect
 	golang.org/x/sys v0.2.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
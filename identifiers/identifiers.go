@@ -1,6 +1,9 @@
 package identifiers
 
-import "errors"
+import (
+	"bytes"
+	"errors"
+)
 
 var (
 	GlobalPlatformDefaultKey = []byte{0x40, 0x41, 0x42, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49, 0x4a, 0x4b, 0x4c, 0x4d, 0x4e, 0x4f}
@@ -19,6 +22,7 @@ var (
 	KeycardDefaultInstanceIndex = 1
 
 	ErrInvalidInstanceIndex = errors.New("instance index must be between 1 and 255")
+	ErrNotKeycardInstance   = errors.New("not a keycard instance AID")
 )
 
 func KeycardInstanceAID(index int) ([]byte, error) {
@@ -28,3 +32,12 @@ func KeycardInstanceAID(index int) ([]byte, error) {
 
 	return append(KeycardAID, byte(index)), nil
 }
+
+// KeycardInstanceIndex returns the index of the Keycard instance with the specified AID.
+func KeycardInstanceIndex(aid []byte) (int, error) {
+	if len(aid) != len(KeycardAID)+1 || !bytes.HasPrefix(aid, KeycardAID) || aid[len(aid)-1] == 0x00 {
+		return 0, ErrNotKeycardInstance
+	}
+
+	return int(aid[len(aid)-1]), nil
+}
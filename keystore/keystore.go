@@ -0,0 +1,81 @@
+// Package keystore exports keys from the card as Ethereum keystore v3 JSON files.
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	keycard "github.com/status-im/keycard-go"
+)
+
+var ErrInvalidScryptParams = errors.New("scrypt N must be a power of 2 greater than 1 and P must be greater than 0")
+
+// ScryptParams are the scrypt parameters used to derive the encryption key from the passphrase.
+type ScryptParams struct {
+	N int
+	P int
+}
+
+var (
+	// StandardScryptParams are the parameters used by go-ethereum by default.
+	StandardScryptParams = ScryptParams{N: keystore.StandardScryptN, P: keystore.StandardScryptP}
+	// LightScryptParams use less memory and CPU, at the cost of being easier to brute force.
+	LightScryptParams = ScryptParams{N: keystore.LightScryptN, P: keystore.LightScryptP}
+)
+
+func (p ScryptParams) validate() error {
+	if p.N <= 1 || p.N&(p.N-1) != 0 || p.P <= 0 {
+		return ErrInvalidScryptParams
+	}
+
+	return nil
+}
+
+// KeyExporter exports keys from the card. It's implemented by keycard.CommandSet.
+type KeyExporter interface {
+	ExportStatusKey(path string, private bool) (*keycard.ExportedKey, error)
+}
+
+// ExportChatKey exports the chat key and returns it as keystore v3 JSON.
+func ExportChatKey(e KeyExporter, passphrase string, params ScryptParams) ([]byte, error) {
+	return Export(e, keycard.PathChatKey, passphrase, params)
+}
+
+// Export exports the private key at path and returns it as keystore v3 JSON.
+// The card only allows the export of private keys under the EIP-1581 path,
+// keycard.ErrPrivateExportNotAllowed is returned for any other path.
+func Export(e KeyExporter, path string, passphrase string, params ScryptParams) ([]byte, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	key, err := e.ExportStatusKey(path, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return Encrypt(key.PrivateKey, passphrase, params)
+}
+
+// Encrypt encrypts privKey with passphrase and returns it as keystore v3 JSON.
+func Encrypt(privKey *ecdsa.PrivateKey, passphrase string, params ScryptParams) ([]byte, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &keystore.Key{
+		Id:         id,
+		Address:    crypto.PubkeyToAddress(privKey.PublicKey),
+		PrivateKey: privKey,
+	}
+
+	return keystore.EncryptKey(key, passphrase, params.N, params.P)
+}
@@ -0,0 +1,60 @@
+package keystore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	keycard "github.com/status-im/keycard-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExporter struct {
+	key  *keycard.ExportedKey
+	path string
+}
+
+func (e *fakeExporter) ExportStatusKey(path string, private bool) (*keycard.ExportedKey, error) {
+	e.path = path
+	if !private {
+		return nil, keycard.ErrPrivateExportNotAllowed
+	}
+
+	return e.key, nil
+}
+
+func TestExportChatKey(t *testing.T) {
+	privKey, err := crypto.HexToECDSA("4646464646464646464646464646464646464646464646464646464646464646")
+	assert.NoError(t, err)
+
+	e := &fakeExporter{key: &keycard.ExportedKey{PrivateKey: privKey}}
+	data, err := ExportChatKey(e, "secret", LightScryptParams)
+	assert.NoError(t, err)
+	assert.Equal(t, keycard.PathChatKey, e.path)
+
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, float64(3), raw["version"])
+	assert.Equal(t, "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f", raw["address"])
+	kdfParams := raw["crypto"].(map[string]interface{})["kdfparams"].(map[string]interface{})
+	assert.Equal(t, float64(LightScryptParams.N), kdfParams["n"])
+
+	key, err := keystore.DecryptKey(data, "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, privKey.D, key.PrivateKey.D)
+	assert.Equal(t, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", key.Address.Hex())
+
+	_, err = keystore.DecryptKey(data, "wrong")
+	assert.Error(t, err)
+}
+
+func TestExport_InvalidScryptParams(t *testing.T) {
+	e := &fakeExporter{}
+	_, err := Export(e, keycard.PathChatKey, "secret", ScryptParams{N: 1000, P: 1})
+	assert.Equal(t, ErrInvalidScryptParams, err)
+
+	_, err = Export(e, keycard.PathChatKey, "secret", ScryptParams{N: 1024, P: 0})
+	assert.Equal(t, ErrInvalidScryptParams, err)
+	assert.Equal(t, "", e.path)
+}
@@ -0,0 +1,139 @@
+package monitor
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// FakeBackend is a Backend whose readers and cards are changed by calling its methods.
+// It's meant for tests.
+type FakeBackend struct {
+	mu      sync.Mutex
+	states  []*ReaderState
+	err     error
+	changed chan struct{}
+}
+
+// NewFakeBackend returns a FakeBackend with the specified empty readers.
+func NewFakeBackend(readers ...string) *FakeBackend {
+	b := &FakeBackend{changed: make(chan struct{})}
+	for _, r := range readers {
+		b.states = append(b.states, &ReaderState{Reader: r})
+	}
+
+	return b
+}
+
+// AttachReader adds an empty reader.
+func (b *FakeBackend) AttachReader(reader string) {
+	b.update(func() {
+		b.states = append(b.states, &ReaderState{Reader: reader})
+	})
+}
+
+// DetachReader removes a reader.
+func (b *FakeBackend) DetachReader(reader string) {
+	b.update(func() {
+		states := make([]*ReaderState, 0, len(b.states))
+		for _, s := range b.states {
+			if s.Reader != reader {
+				states = append(states, s)
+			}
+		}
+
+		b.states = states
+	})
+}
+
+// InsertCard inserts a card with the specified ATR in reader.
+func (b *FakeBackend) InsertCard(reader string, atr []byte) {
+	b.update(func() {
+		if s := b.find(reader); s != nil {
+			s.CardPresent = true
+			s.ATR = atr
+		}
+	})
+}
+
+// RemoveCard removes the card from reader.
+func (b *FakeBackend) RemoveCard(reader string) {
+	b.update(func() {
+		if s := b.find(reader); s != nil {
+			s.CardPresent = false
+			s.ATR = nil
+		}
+	})
+}
+
+// Fail makes WaitForChange return err.
+func (b *FakeBackend) Fail(err error) {
+	b.update(func() {
+		b.err = err
+	})
+}
+
+// WaitForChange implements the Backend interface.
+func (b *FakeBackend) WaitForChange(known []*ReaderState, timeout time.Duration) ([]*ReaderState, error) {
+	b.mu.Lock()
+	if b.err != nil || known == nil || !equalStates(known, b.states) {
+		defer b.mu.Unlock()
+		return b.copyStates(), b.err
+	}
+
+	changed := b.changed
+	b.mu.Unlock()
+
+	select {
+	case <-changed:
+	case <-time.After(timeout):
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.copyStates(), b.err
+}
+
+func (b *FakeBackend) update(f func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f()
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func (b *FakeBackend) find(reader string) *ReaderState {
+	for _, s := range b.states {
+		if s.Reader == reader {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func (b *FakeBackend) copyStates() []*ReaderState {
+	states := make([]*ReaderState, 0, len(b.states))
+	for _, s := range b.states {
+		c := *s
+		states = append(states, &c)
+	}
+
+	return states
+}
+
+func equalStates(a, b []*ReaderState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Reader != b[i].Reader || a[i].CardPresent != b[i].CardPresent || !bytes.Equal(a[i].ATR, b[i].ATR) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,295 @@
+// Package monitor reports readers being attached or detached and cards being inserted or removed.
+package monitor
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var logger = log.New("package", "keycard-go/monitor")
+
+// waitTimeout is the longest time the monitor waits for a change before checking if it has been stopped.
+const waitTimeout = 500 * time.Millisecond
+
+var ErrAlreadyStarted = errors.New("monitor already started")
+
+// EventType is the type of a monitor event.
+type EventType int
+
+const (
+	EventReaderAttached EventType = iota
+	EventReaderDetached
+	EventCardInserted
+	EventCardRemoved
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventReaderAttached:
+		return "reader attached"
+	case EventReaderDetached:
+		return "reader detached"
+	case EventCardInserted:
+		return "card inserted"
+	case EventCardRemoved:
+		return "card removed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a change in a reader. ATR is only set for EventCardInserted.
+type Event struct {
+	Type   EventType
+	Reader string
+	ATR    []byte
+}
+
+// ReaderState is the state of a reader as reported by a Backend.
+type ReaderState struct {
+	Reader      string
+	CardPresent bool
+	ATR         []byte
+}
+
+// Backend reports the state of the readers. pcsc.MonitorBackend implements it with PC/SC.
+type Backend interface {
+	// WaitForChange blocks until the reader states differ from known or until timeout expires,
+	// and returns the current states. With nil known, it returns the current states immediately.
+	WaitForChange(known []*ReaderState, timeout time.Duration) ([]*ReaderState, error)
+}
+
+// Invalidator is implemented by the objects keeping the state of a card, like the command sets.
+type Invalidator interface {
+	Invalidate()
+}
+
+// Monitor emits the events of the readers reported by a Backend.
+type Monitor struct {
+	backend   Backend
+	events    chan Event
+	mu        sync.Mutex
+	callbacks []func(Event)
+	started   bool
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+	err       error
+}
+
+// New returns a Monitor using backend.
+func New(backend Backend) *Monitor {
+	return &Monitor{
+		backend: backend,
+		events:  make(chan Event),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Events returns the channel of the events. The events are queued until they're received, so none is lost
+// if the channel is read slowly. The channel is closed when the monitor stops, dropping the queued events
+// if stopped with Stop.
+func (m *Monitor) Events() <-chan Event {
+	return m.events
+}
+
+// OnEvent registers a callback called, in the monitor goroutine, for each event.
+// The callbacks are called before the event is sent on the Events channel.
+func (m *Monitor) OnEvent(callback func(Event)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.callbacks = append(m.callbacks, callback)
+}
+
+// InvalidateOnRemoval invalidates the state kept by invalidators when the card is removed from reader,
+// or when reader is detached. An empty reader matches any reader.
+func (m *Monitor) InvalidateOnRemoval(reader string, invalidators ...Invalidator) {
+	m.OnEvent(func(e Event) {
+		if e.Type != EventCardRemoved || (reader != "" && e.Reader != reader) {
+			return
+		}
+
+		for _, i := range invalidators {
+			i.Invalidate()
+		}
+	})
+}
+
+// Start starts monitoring in a new goroutine. The initial readers and cards are reported as attached and inserted.
+// A stopped monitor can't be started again.
+func (m *Monitor) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		return ErrAlreadyStarted
+	}
+
+	m.started = true
+	go m.run()
+
+	return nil
+}
+
+// Stop stops monitoring and returns the backend error that stopped the monitor, if any.
+func (m *Monitor) Stop() error {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+
+	m.mu.Lock()
+	started := m.started
+	m.mu.Unlock()
+
+	if started {
+		<-m.done
+	}
+
+	return m.err
+}
+
+func (m *Monitor) run() {
+	defer close(m.done)
+
+	queue := make(chan Event)
+	delivered := make(chan struct{})
+	go m.deliver(queue, delivered)
+
+	defer func() {
+		close(queue)
+		<-delivered
+	}()
+
+	var known []*ReaderState
+	for {
+		select {
+		case <-m.stop:
+			return
+		default:
+		}
+
+		states, err := m.backend.WaitForChange(known, waitTimeout)
+		if err != nil {
+			logger.Error("monitor stopped", "error", err)
+			m.err = err
+			return
+		}
+
+		if states == nil {
+			states = []*ReaderState{}
+		}
+
+		for _, e := range diff(known, states) {
+			m.emit(e, queue)
+		}
+
+		known = states
+	}
+}
+
+func (m *Monitor) emit(e Event, queue chan<- Event) {
+	logger.Debug("event", "type", e.Type, "reader", e.Reader)
+
+	m.mu.Lock()
+	callbacks := append([]func(Event){}, m.callbacks...)
+	m.mu.Unlock()
+
+	// the callbacks run first, so that the state is already invalidated when the event is received from the channel
+	for _, cb := range callbacks {
+		cb(e)
+	}
+
+	select {
+	case queue <- e:
+	case <-m.stop:
+	}
+}
+
+// deliver sends the events received from queue to the events channel, keeping the ones not received yet.
+// It closes the events channel when queue is closed and all the events are delivered, or when the monitor
+// is stopped.
+func (m *Monitor) deliver(queue <-chan Event, delivered chan<- struct{}) {
+	defer close(delivered)
+	defer close(m.events)
+
+	pending := make([]Event, 0)
+	for queue != nil || len(pending) > 0 {
+		var (
+			out  chan<- Event
+			next Event
+		)
+
+		if len(pending) > 0 {
+			out = m.events
+			next = pending[0]
+		}
+
+		select {
+		case e, ok := <-queue:
+			if !ok {
+				queue = nil
+				continue
+			}
+
+			pending = append(pending, e)
+		case out <- next:
+			pending = pending[1:]
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// diff returns the events turning old into new.
+func diff(old, new []*ReaderState) []Event {
+	events := make([]Event, 0)
+
+	oldStates := make(map[string]*ReaderState)
+	for _, s := range old {
+		oldStates[s.Reader] = s
+	}
+
+	newStates := make(map[string]*ReaderState)
+	for _, s := range new {
+		newStates[s.Reader] = s
+	}
+
+	for _, s := range old {
+		if _, ok := newStates[s.Reader]; ok {
+			continue
+		}
+
+		if s.CardPresent {
+			events = append(events, Event{Type: EventCardRemoved, Reader: s.Reader})
+		}
+
+		events = append(events, Event{Type: EventReaderDetached, Reader: s.Reader})
+	}
+
+	for _, s := range new {
+		o, ok := oldStates[s.Reader]
+		if !ok {
+			events = append(events, Event{Type: EventReaderAttached, Reader: s.Reader})
+			o = &ReaderState{Reader: s.Reader}
+		}
+
+		// a different ATR means the card has been swapped between two calls
+		swapped := o.CardPresent && s.CardPresent && !bytes.Equal(o.ATR, s.ATR)
+
+		if o.CardPresent && (!s.CardPresent || swapped) {
+			events = append(events, Event{Type: EventCardRemoved, Reader: s.Reader})
+		}
+
+		if s.CardPresent && (!o.CardPresent || swapped) {
+			events = append(events, Event{Type: EventCardInserted, Reader: s.Reader, ATR: s.ATR})
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,122 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeInvalidator struct {
+	count int32
+}
+
+func (i *fakeInvalidator) Invalidate() {
+	atomic.AddInt32(&i.count, 1)
+}
+
+func nextEvent(t *testing.T, m *Monitor) Event {
+	select {
+	case e, ok := <-m.Events():
+		require.True(t, ok, "events channel closed")
+		return e
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for event")
+	}
+
+	return Event{}
+}
+
+func TestMonitor(t *testing.T) {
+	atr := []byte{0x3B, 0x80, 0x80, 0x01, 0x01}
+	backend := NewFakeBackend("Reader 0")
+	backend.InsertCard("Reader 0", atr)
+
+	m := New(backend)
+	invalidator := &fakeInvalidator{}
+	m.InvalidateOnRemoval("Reader 0", invalidator)
+
+	callbackEvents := make(chan Event, 10)
+	m.OnEvent(func(e Event) { callbackEvents <- e })
+
+	require.NoError(t, m.Start())
+	assert.Equal(t, ErrAlreadyStarted, m.Start())
+
+	// initial state
+	assert.Equal(t, Event{Type: EventReaderAttached, Reader: "Reader 0"}, nextEvent(t, m))
+	assert.Equal(t, Event{Type: EventCardInserted, Reader: "Reader 0", ATR: atr}, nextEvent(t, m))
+	assert.Equal(t, EventReaderAttached, (<-callbackEvents).Type)
+
+	backend.RemoveCard("Reader 0")
+	assert.Equal(t, Event{Type: EventCardRemoved, Reader: "Reader 0"}, nextEvent(t, m))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&invalidator.count))
+
+	backend.AttachReader("Reader 1")
+	assert.Equal(t, Event{Type: EventReaderAttached, Reader: "Reader 1"}, nextEvent(t, m))
+
+	backend.InsertCard("Reader 1", atr)
+	assert.Equal(t, EventCardInserted, nextEvent(t, m).Type)
+
+	backend.DetachReader("Reader 1")
+	assert.Equal(t, Event{Type: EventCardRemoved, Reader: "Reader 1"}, nextEvent(t, m))
+	assert.Equal(t, Event{Type: EventReaderDetached, Reader: "Reader 1"}, nextEvent(t, m))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&invalidator.count))
+
+	assert.NoError(t, m.Stop())
+	_, ok := <-m.Events()
+	assert.False(t, ok)
+}
+
+func TestMonitor_SlowConsumer(t *testing.T) {
+	backend := NewFakeBackend()
+	m := New(backend)
+
+	var count int32
+	m.OnEvent(func(Event) { atomic.AddInt32(&count, 1) })
+	require.NoError(t, m.Start())
+
+	// many more events than a channel buffer would hold, none is received until the end
+	readers := 100
+	for i := 0; i < readers; i++ {
+		backend.AttachReader(fmt.Sprintf("Reader %d", i))
+	}
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&count) == int32(readers)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	for i := 0; i < readers; i++ {
+		assert.Equal(t, Event{Type: EventReaderAttached, Reader: fmt.Sprintf("Reader %d", i)}, nextEvent(t, m))
+	}
+
+	assert.NoError(t, m.Stop())
+}
+
+func TestMonitor_BackendError(t *testing.T) {
+	backend := NewFakeBackend()
+	m := New(backend)
+	require.NoError(t, m.Start())
+
+	err := errors.New("service stopped")
+	backend.Fail(err)
+
+	_, ok := <-m.Events()
+	assert.False(t, ok)
+	assert.Equal(t, err, m.Stop())
+}
+
+func TestDiff_CardSwapped(t *testing.T) {
+	old := []*ReaderState{{Reader: "Reader 0", CardPresent: true, ATR: []byte{0x01}}}
+	new := []*ReaderState{{Reader: "Reader 0", CardPresent: true, ATR: []byte{0x02}}}
+
+	assert.Equal(t, []Event{
+		{Type: EventCardRemoved, Reader: "Reader 0"},
+		{Type: EventCardInserted, Reader: "Reader 0", ATR: []byte{0x02}},
+	}, diff(old, new))
+
+	assert.Empty(t, diff(new, new))
+}
@@ -0,0 +1,196 @@
+//go:build pcsc
+// +build pcsc
+
+package pcsc
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/ebfe/scard"
+	"github.com/status-im/keycard-go/monitor"
+)
+
+// pnpNotification is the special reader name used to be notified of readers being attached or detached.
+const pnpNotification = `\\?PnP?\Notification`
+
+// MonitorBackend implements monitor.Backend with SCardGetStatusChange.
+type MonitorBackend struct {
+	ctx *scard.Context
+	// pnp is true if the resource manager supports reader notifications.
+	pnp bool
+	// pnpState is the last state of the reader notifications.
+	pnpState scard.StateFlag
+}
+
+// NewMonitorBackend establishes a new PC/SC context and returns a MonitorBackend using it.
+// Release must be called when the backend is not used anymore.
+func NewMonitorBackend() (*MonitorBackend, error) {
+	ctx, err := scard.EstablishContext()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &MonitorBackend{ctx: ctx}
+
+	states := []scard.ReaderState{{Reader: pnpNotification, CurrentState: scard.StateUnaware}}
+	if err := ctx.GetStatusChange(states, 0); err == nil && states[0].EventState&scard.StateUnknown == 0 {
+		b.pnp = true
+		b.pnpState = states[0].EventState &^ scard.StateChanged
+	} else {
+		logger.Debug("reader notifications not supported, readers are polled", "error", err)
+	}
+
+	return b, nil
+}
+
+// WaitForChange blocks until the reader states differ from known or until timeout expires,
+// and returns the current states. With nil known, it returns the current states immediately.
+func (b *MonitorBackend) WaitForChange(known []*monitor.ReaderState, timeout time.Duration) ([]*monitor.ReaderState, error) {
+	readers, err := b.listReaders()
+	if err != nil {
+		return nil, err
+	}
+
+	if known == nil || !sameReaders(known, readers) {
+		return b.currentStates(readers)
+	}
+
+	states := make([]scard.ReaderState, len(readers), len(readers)+1)
+	for i, s := range known {
+		states[i] = scard.ReaderState{Reader: s.Reader, CurrentState: scard.StateEmpty}
+		if s.CardPresent {
+			states[i].CurrentState = scard.StatePresent
+		}
+	}
+
+	if b.pnp {
+		states = append(states, scard.ReaderState{Reader: pnpNotification, CurrentState: b.pnpState})
+	} else if len(states) == 0 {
+		// without notifications the new readers are found listing them again on the next call
+		time.Sleep(timeout)
+		return known, nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		err := b.ctx.GetStatusChange(states, remaining)
+		if err == scard.ErrTimeout {
+			return known, nil
+		} else if err == scard.ErrUnknownReader || err == scard.ErrReaderUnavailable {
+			return b.listCurrentStates()
+		} else if err != nil {
+			return nil, err
+		}
+
+		if b.pnp {
+			pnp := &states[len(states)-1]
+			if pnp.EventState&scard.StateChanged != 0 {
+				b.pnpState = pnp.EventState &^ scard.StateChanged
+				return b.listCurrentStates()
+			}
+		}
+
+		readerStates := states[:len(readers)]
+		for _, s := range readerStates {
+			if s.EventState&(scard.StateUnknown|scard.StateUnavailable) != 0 {
+				return b.listCurrentStates()
+			}
+		}
+
+		current := toMonitorStates(readerStates)
+		if !sameStates(known, current) || !time.Now().Before(deadline) {
+			return current, nil
+		}
+
+		// the state changed without changing the card presence, like when a card is connected
+		for i := range readerStates {
+			readerStates[i].CurrentState = readerStates[i].EventState &^ scard.StateChanged
+		}
+	}
+}
+
+// Release releases the PC/SC context.
+func (b *MonitorBackend) Release() error {
+	return b.ctx.Release()
+}
+
+func (b *MonitorBackend) listReaders() ([]string, error) {
+	readers, err := b.ctx.ListReaders()
+	if err == scard.ErrNoReadersAvailable {
+		return []string{}, nil
+	}
+
+	return readers, err
+}
+
+func (b *MonitorBackend) listCurrentStates() ([]*monitor.ReaderState, error) {
+	readers, err := b.listReaders()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.currentStates(readers)
+}
+
+// currentStates returns the current states of readers without waiting.
+func (b *MonitorBackend) currentStates(readers []string) ([]*monitor.ReaderState, error) {
+	states := make([]scard.ReaderState, len(readers))
+	for i, r := range readers {
+		states[i] = scard.ReaderState{Reader: r, CurrentState: scard.StateUnaware}
+	}
+
+	if len(states) > 0 {
+		if err := b.ctx.GetStatusChange(states, 0); err != nil && err != scard.ErrTimeout {
+			return nil, err
+		}
+	}
+
+	return toMonitorStates(states), nil
+}
+
+func toMonitorStates(states []scard.ReaderState) []*monitor.ReaderState {
+	result := make([]*monitor.ReaderState, len(states))
+	for i, s := range states {
+		result[i] = &monitor.ReaderState{Reader: s.Reader}
+		if s.EventState&scard.StatePresent != 0 {
+			result[i].CardPresent = true
+			result[i].ATR = append([]byte{}, s.Atr...)
+		}
+	}
+
+	return result
+}
+
+func sameReaders(states []*monitor.ReaderState, readers []string) bool {
+	if len(states) != len(readers) {
+		return false
+	}
+
+	for i, s := range states {
+		if s.Reader != readers[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameStates(a, b []*monitor.ReaderState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Reader != b[i].Reader || a[i].CardPresent != b[i].CardPresent || !bytes.Equal(a[i].ATR, b[i].ATR) {
+			return false
+		}
+	}
+
+	return true
+}
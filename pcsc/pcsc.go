@@ -0,0 +1,214 @@
+// Package pcsc implements io.Transmitter over PC/SC.
+//
+// The PC/SC binding is github.com/ebfe/scard, which needs cgo and the PC/SC headers
+// (libpcsclite on Linux), so it's only built with the pcsc build tag:
+//
+//	go build -tags pcsc
+//
+// Without the tag the package can still be used with any SCardContext implementation.
+package pcsc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var logger = log.New("package", "keycard-go/pcsc")
+
+// ShareMode is the PC/SC share mode of a connection.
+type ShareMode uint32
+
+// Share modes, with the PC/SC values.
+const (
+	ShareExclusive ShareMode = 0x01
+	ShareShared    ShareMode = 0x02
+)
+
+// Protocol is a PC/SC transmission protocol.
+type Protocol uint32
+
+// Protocols, with the PC/SC values.
+const (
+	ProtocolUndefined Protocol = 0x00
+	ProtocolT0        Protocol = 0x01
+	ProtocolT1        Protocol = 0x02
+	ProtocolAny                = ProtocolT0 | ProtocolT1
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolT0:
+		return "T=0"
+	case ProtocolT1:
+		return "T=1"
+	case ProtocolAny:
+		return "T=0|T=1"
+	default:
+		return "undefined"
+	}
+}
+
+// Disposition is the action on the card when disconnecting.
+type Disposition uint32
+
+// Dispositions, with the PC/SC values.
+const (
+	LeaveCard   Disposition = 0x00
+	ResetCard   Disposition = 0x01
+	UnpowerCard Disposition = 0x02
+)
+
+var (
+	ErrNoReaders = errors.New("no readers found")
+	// ErrNoCard is returned when the readers are empty. SCardContext implementations return it,
+	// possibly wrapped, when connecting to an empty reader.
+	ErrNoCard             = errors.New("no card found in the readers")
+	ErrProtocolNotMatched = errors.New("card protocol doesn't match the requested one")
+)
+
+// CardStatus is the status of a connected card.
+type CardStatus struct {
+	Reader         string
+	ActiveProtocol Protocol
+	ATR            []byte
+}
+
+// SCardContext is a PC/SC context.
+type SCardContext interface {
+	ListReaders() ([]string, error)
+	Connect(reader string, mode ShareMode, protocol Protocol) (SCard, error)
+	Release() error
+}
+
+// SCard is a card connected through an SCardContext.
+type SCard interface {
+	Transmit(cmd []byte) ([]byte, error)
+	Status() (*CardStatus, error)
+	Disconnect(d Disposition) error
+}
+
+// Context lists readers and connects to cards.
+type Context struct {
+	ctx SCardContext
+}
+
+// NewContext returns a Context using ctx.
+func NewContext(ctx SCardContext) *Context {
+	return &Context{ctx: ctx}
+}
+
+// Readers returns the names of the connected readers.
+func (c *Context) Readers() ([]string, error) {
+	readers, err := c.ctx.ListReaders()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(readers) == 0 {
+		return nil, ErrNoReaders
+	}
+
+	return readers, nil
+}
+
+// Connect connects to the card in reader, negotiating one of the protocols in protocol.
+func (c *Context) Connect(reader string, mode ShareMode, protocol Protocol) (*Transmitter, error) {
+	card, err := c.ctx.Connect(reader, mode, protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := card.Status()
+	if err != nil {
+		_ = card.Disconnect(LeaveCard)
+		return nil, err
+	}
+
+	if status.ActiveProtocol&protocol == 0 {
+		_ = card.Disconnect(LeaveCard)
+		return nil, fmt.Errorf("%w: %s", ErrProtocolNotMatched, status.ActiveProtocol)
+	}
+
+	if status.Reader == "" {
+		status.Reader = reader
+	}
+
+	logger.Debug("card connected", "reader", status.Reader, "protocol", status.ActiveProtocol)
+
+	return &Transmitter{
+		card:     card,
+		reader:   status.Reader,
+		atr:      status.ATR,
+		protocol: status.ActiveProtocol,
+	}, nil
+}
+
+// ConnectFirst connects to the card in the first reader that has one.
+// If no card can be connected, it returns the error of the last reader with a card,
+// or ErrNoCard if all the readers are empty.
+func (c *Context) ConnectFirst(mode ShareMode, protocol Protocol) (*Transmitter, error) {
+	readers, err := c.Readers()
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, reader := range readers {
+		t, err := c.Connect(reader, mode, protocol)
+		if err == nil {
+			return t, nil
+		}
+
+		logger.Debug("connection failed", "reader", reader, "error", err)
+
+		if !errors.Is(err, ErrNoCard) {
+			lastErr = err
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return nil, ErrNoCard
+}
+
+// Release releases the PC/SC context. Connected cards must be closed first.
+func (c *Context) Release() error {
+	return c.ctx.Release()
+}
+
+// Transmitter implements io.Transmitter sending commands to a connected card.
+type Transmitter struct {
+	card     SCard
+	reader   string
+	atr      []byte
+	protocol Protocol
+}
+
+// Transmit sends a raw command and returns the raw response.
+func (t *Transmitter) Transmit(cmd []byte) ([]byte, error) {
+	return t.card.Transmit(cmd)
+}
+
+// Reader returns the name of the reader of the card.
+func (t *Transmitter) Reader() string {
+	return t.reader
+}
+
+// ATR returns the Answer To Reset of the card.
+func (t *Transmitter) ATR() []byte {
+	return t.atr
+}
+
+// Protocol returns the negotiated protocol.
+func (t *Transmitter) Protocol() Protocol {
+	return t.protocol
+}
+
+// Close disconnects from the card, leaving it powered.
+func (t *Transmitter) Close() error {
+	return t.card.Disconnect(LeaveCard)
+}
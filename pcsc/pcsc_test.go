@@ -0,0 +1,126 @@
+package pcsc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/io"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSCard struct {
+	status       *CardStatus
+	commands     [][]byte
+	disconnected bool
+}
+
+func (c *fakeSCard) Transmit(cmd []byte) ([]byte, error) {
+	c.commands = append(c.commands, cmd)
+	return []byte{0x90, 0x00}, nil
+}
+
+func (c *fakeSCard) Status() (*CardStatus, error) {
+	return c.status, nil
+}
+
+func (c *fakeSCard) Disconnect(d Disposition) error {
+	c.disconnected = true
+	return nil
+}
+
+// fakeSCardContext has a card in the readers listed in cards.
+// Connecting to the readers listed in errs fails with the specified error.
+type fakeSCardContext struct {
+	readers []string
+	cards   map[string]*fakeSCard
+	errs    map[string]error
+	mode    ShareMode
+}
+
+func (c *fakeSCardContext) ListReaders() ([]string, error) {
+	return c.readers, nil
+}
+
+func (c *fakeSCardContext) Connect(reader string, mode ShareMode, protocol Protocol) (SCard, error) {
+	if err, ok := c.errs[reader]; ok {
+		return nil, err
+	}
+
+	card, ok := c.cards[reader]
+	if !ok {
+		return nil, ErrNoCard
+	}
+
+	c.mode = mode
+	return card, nil
+}
+
+func (c *fakeSCardContext) Release() error {
+	return nil
+}
+
+func TestContext_ConnectFirst(t *testing.T) {
+	card := &fakeSCard{status: &CardStatus{ActiveProtocol: ProtocolT1, ATR: []byte{0x3B, 0x80}}}
+	fake := &fakeSCardContext{
+		readers: []string{"Reader 0", "Reader 1"},
+		cards:   map[string]*fakeSCard{"Reader 1": card},
+	}
+
+	ctx := NewContext(fake)
+	readers, err := ctx.Readers()
+	require.NoError(t, err)
+	assert.Equal(t, fake.readers, readers)
+
+	tr, err := ctx.ConnectFirst(ShareExclusive, ProtocolAny)
+	require.NoError(t, err)
+	assert.Equal(t, ShareExclusive, fake.mode)
+	assert.Equal(t, "Reader 1", tr.Reader())
+	assert.Equal(t, []byte{0x3B, 0x80}, tr.ATR())
+	assert.Equal(t, ProtocolT1, tr.Protocol())
+	assert.Equal(t, "T=1", tr.Protocol().String())
+
+	// the transmitter works with the io package
+	resp, err := io.NewNormalChannel(tr).Send(apdu.NewCommand(0x00, 0xA4, 0x04, 0x00, []byte{0xA0}))
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x9000), resp.Sw)
+	assert.Len(t, card.commands, 1)
+
+	assert.NoError(t, tr.Close())
+	assert.True(t, card.disconnected)
+}
+
+func TestContext_ConnectErrors(t *testing.T) {
+	ctx := NewContext(&fakeSCardContext{readers: []string{}})
+	_, err := ctx.ConnectFirst(ShareShared, ProtocolAny)
+	assert.Equal(t, ErrNoReaders, err)
+
+	ctx = NewContext(&fakeSCardContext{readers: []string{"Reader 0"}})
+	_, err = ctx.ConnectFirst(ShareShared, ProtocolAny)
+	assert.Equal(t, ErrNoCard, err)
+
+	card := &fakeSCard{status: &CardStatus{ActiveProtocol: ProtocolT0}}
+	ctx = NewContext(&fakeSCardContext{
+		readers: []string{"Reader 0"},
+		cards:   map[string]*fakeSCard{"Reader 0": card},
+	})
+
+	_, err = ctx.Connect("Reader 0", ShareShared, ProtocolT1)
+	assert.EqualError(t, err, "card protocol doesn't match the requested one: T=0")
+	assert.True(t, errors.Is(err, ErrProtocolNotMatched))
+	assert.True(t, card.disconnected)
+
+	// errors of readers with a card are not hidden by empty readers
+	_, err = ctx.ConnectFirst(ShareShared, ProtocolT1)
+	assert.True(t, errors.Is(err, ErrProtocolNotMatched))
+
+	errSharingViolation := errors.New("sharing violation")
+	ctx = NewContext(&fakeSCardContext{
+		readers: []string{"Reader 0", "Reader 1"},
+		errs:    map[string]error{"Reader 0": errSharingViolation},
+	})
+
+	_, err = ctx.ConnectFirst(ShareExclusive, ProtocolAny)
+	assert.Equal(t, errSharingViolation, err)
+}
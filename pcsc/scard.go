@@ -0,0 +1,73 @@
+//go:build pcsc
+// +build pcsc
+
+package pcsc
+
+import (
+	"github.com/ebfe/scard"
+)
+
+// EstablishContext establishes a PC/SC context with the system resource manager.
+func EstablishContext() (*Context, error) {
+	ctx, err := scard.EstablishContext()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewContext(&scardContext{ctx}), nil
+}
+
+// scardContext implements SCardContext with ebfe/scard.
+type scardContext struct {
+	ctx *scard.Context
+}
+
+func (c *scardContext) ListReaders() ([]string, error) {
+	readers, err := c.ctx.ListReaders()
+	if err == scard.ErrNoReadersAvailable {
+		return []string{}, nil
+	}
+
+	return readers, err
+}
+
+func (c *scardContext) Connect(reader string, mode ShareMode, protocol Protocol) (SCard, error) {
+	card, err := c.ctx.Connect(reader, scard.ShareMode(mode), scard.Protocol(protocol))
+	if err == scard.ErrNoSmartcard || err == scard.ErrRemovedCard {
+		return nil, ErrNoCard
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &scardCard{card}, nil
+}
+
+func (c *scardContext) Release() error {
+	return c.ctx.Release()
+}
+
+// scardCard implements SCard with ebfe/scard.
+type scardCard struct {
+	card *scard.Card
+}
+
+func (c *scardCard) Transmit(cmd []byte) ([]byte, error) {
+	return c.card.Transmit(cmd)
+}
+
+func (c *scardCard) Status() (*CardStatus, error) {
+	status, err := c.card.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CardStatus{
+		Reader:         status.Reader,
+		ActiveProtocol: Protocol(status.ActiveProtocol),
+		ATR:            status.Atr,
+	}, nil
+}
+
+func (c *scardCard) Disconnect(d Disposition) error {
+	return c.card.Disconnect(scard.Disposition(d))
+}
@@ -0,0 +1,111 @@
+package keycard
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/derivationpath"
+)
+
+// Paths of the keys used by Status based applications.
+const (
+	PathMaster        = "m"
+	PathWalletRoot    = "m/44'/60'/0'/0"
+	PathEIP1581       = "m/43'/60'/1581'"
+	PathChatKey       = "m/43'/60'/1581'/0'/0"
+	PathEncryptionKey = "m/43'/60'/1581'/1'/0"
+)
+
+// ErrPrivateExportNotAllowed is returned when exporting a private key outside the EIP-1581 subtree.
+// The card only allows exporting private keys of non-wallet keys (like the chat and encryption keys),
+// wallet keys never leave the card.
+var ErrPrivateExportNotAllowed = errors.New("private keys can only be exported under the EIP-1581 path " + PathEIP1581)
+
+// ExportedKey is a key exported from the card.
+type ExportedKey struct {
+	// Path is the absolute path of the key.
+	Path string
+	// PrivateKey is nil if only the public key has been exported.
+	PrivateKey *ecdsa.PrivateKey
+	PublicKey  *ecdsa.PublicKey
+	Address    common.Address
+}
+
+// ExportChatKey exports the private chat key at PathChatKey.
+func (cs *CommandSet) ExportChatKey() (*ExportedKey, error) {
+	return cs.ExportStatusKey(PathChatKey, true)
+}
+
+// ExportEncryptionKey exports the private encryption key at PathEncryptionKey.
+func (cs *CommandSet) ExportEncryptionKey() (*ExportedKey, error) {
+	return cs.ExportStatusKey(PathEncryptionKey, true)
+}
+
+// ExportWalletRootKey exports the public key at PathWalletRoot.
+func (cs *CommandSet) ExportWalletRootKey() (*ExportedKey, error) {
+	return cs.ExportStatusKey(PathWalletRoot, false)
+}
+
+// ExportMasterPublicKey exports the master public key.
+func (cs *CommandSet) ExportMasterPublicKey() (*ExportedKey, error) {
+	return cs.ExportStatusKey(PathMaster, false)
+}
+
+// ExportStatusKey exports the key at path without changing the current key.
+// If private is true, the private key is exported as well, which is only allowed
+// under the EIP-1581 subtree.
+func (cs *CommandSet) ExportStatusKey(path string, private bool) (*ExportedKey, error) {
+	absPath, err := cs.resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if private && !isEIP1581Path(absPath) {
+		return nil, ErrPrivateExportNotAllowed
+	}
+
+	privKeyData, pubKeyData, err := cs.ExportKey(true, false, !private, path)
+	if err != nil {
+		if e, ok := err.(*apdu.ErrBadResponse); ok && private && (e.Sw == SwIncorrectP1P2 || e.Sw == SwConditionsNotSatisfied) {
+			return nil, ErrPrivateExportNotAllowed
+		}
+
+		return nil, err
+	}
+
+	key := &ExportedKey{
+		Path: absPath.String(),
+	}
+
+	if private {
+		if key.PrivateKey, err = ethcrypto.ToECDSA(privKeyData); err != nil {
+			return nil, err
+		}
+
+		key.PublicKey = &key.PrivateKey.PublicKey
+	} else if key.PublicKey, err = ethcrypto.UnmarshalPubkey(pubKeyData); err != nil {
+		return nil, err
+	}
+
+	key.Address = ethcrypto.PubkeyToAddress(*key.PublicKey)
+
+	return key, nil
+}
+
+func isEIP1581Path(path *derivationpath.Path) bool {
+	root := derivationpath.MustParse(PathEIP1581)
+	if len(path.Segments) <= len(root.Segments) {
+		return false
+	}
+
+	for i, s := range root.Segments {
+		if path.Segments[i] != s {
+			return false
+		}
+	}
+
+	return true
+}
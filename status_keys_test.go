@@ -0,0 +1,83 @@
+package keycard
+
+import (
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newExportChannel returns a channel exporting privKey, or answering with *sw if not 0.
+func newExportChannel(privKey []byte, sw *uint16) *scriptedChannel {
+	return newScriptedChannel().on(InsExportKey, func(cmd *apdu.Command) *apdu.Response {
+		if *sw != 0 {
+			return response(nil, *sw)
+		}
+
+		if cmd.P2 == P2ExportKeyPrivateAndPublic {
+			return response(append([]byte{0xA1, 0x22, 0x81, 0x20}, privKey...), apdu.SwOK)
+		}
+
+		key, _ := ethcrypto.ToECDSA(privKey)
+		pubKey := ethcrypto.FromECDSAPub(&key.PublicKey)
+		return response(append([]byte{0xA1, 0x43, 0x80, 0x41}, pubKey...), apdu.SwOK)
+	})
+}
+
+func TestCommandSet_ExportChatKey(t *testing.T) {
+	privKey := hexutils.HexToBytes("4646464646464646464646464646464646464646464646464646464646464646")
+	var sw uint16
+	c := newExportChannel(privKey, &sw)
+	cs := NewCommandSet(c)
+
+	key, err := cs.ExportChatKey()
+	assert.NoError(t, err)
+	assert.Equal(t, PathChatKey, key.Path)
+	assert.Equal(t, privKey, ethcrypto.FromECDSA(key.PrivateKey))
+	assert.Equal(t, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", key.Address.Hex())
+
+	assert.Len(t, c.commands, 1)
+	assert.Equal(t, uint8(P1ExportKeyDerive), c.commands[0].P1)
+	assert.Equal(t, uint8(P2ExportKeyPrivateAndPublic), c.commands[0].P2)
+	// the current key path is not changed
+	assert.Nil(t, cs.KeyPath())
+}
+
+func TestCommandSet_ExportWalletRootKey(t *testing.T) {
+	privKey := hexutils.HexToBytes("4646464646464646464646464646464646464646464646464646464646464646")
+	var sw uint16
+	c := newExportChannel(privKey, &sw)
+	cs := NewCommandSet(c)
+
+	key, err := cs.ExportWalletRootKey()
+	assert.NoError(t, err)
+	assert.Equal(t, PathWalletRoot, key.Path)
+	assert.Nil(t, key.PrivateKey)
+	assert.Equal(t, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", key.Address.Hex())
+	assert.Equal(t, uint8(P2ExportKeyPublicOnly), c.commands[0].P2)
+}
+
+func TestCommandSet_ExportStatusKeyNotAllowed(t *testing.T) {
+	var sw uint16
+	c := newExportChannel(nil, &sw)
+	cs := NewCommandSet(c)
+
+	_, err := cs.ExportStatusKey(PathWalletRoot+"/0", true)
+	assert.Equal(t, ErrPrivateExportNotAllowed, err)
+
+	_, err = cs.ExportStatusKey(PathEIP1581, true)
+	assert.Equal(t, ErrPrivateExportNotAllowed, err)
+	assert.Len(t, c.commands, 0)
+
+	// refused by the card
+	sw = SwIncorrectP1P2
+	_, err = cs.ExportStatusKey(PathEIP1581+"/2'/0", true)
+	assert.Equal(t, ErrPrivateExportNotAllowed, err)
+
+	// other errors are returned as they are
+	sw = SwConditionsNotSatisfied
+	_, err = cs.ExportStatusKey(PathWalletRoot, false)
+	assert.Equal(t, apdu.NewErrBadResponse(SwConditionsNotSatisfied, "unexpected response"), err)
+}
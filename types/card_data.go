@@ -0,0 +1,336 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/status-im/keycard-go/apdu"
+)
+
+var (
+	TagCPLC                      = apdu.Tag{0x9F, 0x7F}
+	TagCardData                  = apdu.Tag{0x66}
+	TagCardRecognitionData       = apdu.Tag{0x73}
+	TagCardManagementTypeVersion = apdu.Tag{0x60}
+	TagCardIdentificationScheme  = apdu.Tag{0x63}
+	TagSecureChannelProtocol     = apdu.Tag{0x64}
+	TagOID                       = apdu.Tag{0x06}
+	TagKeyInformationTemplate    = apdu.Tag{0xE0}
+	TagKeyInformationData        = apdu.Tag{0xC0}
+)
+
+const (
+	cplcLength         = 42
+	keyComponentExtend = 0xFF
+)
+
+// globalPlatformOID is the 1.2.840.114283 prefix of the globalplatform OIDs.
+var globalPlatformOID = []int{1, 2, 840, 114283}
+
+var (
+	ErrInvalidCPLC       = errors.New("CPLC data must be 42 bytes")
+	ErrInvalidCPLCDate   = errors.New("invalid CPLC date")
+	ErrInvalidOID        = errors.New("invalid OID")
+	ErrInvalidKeyInfo    = errors.New("invalid key information data")
+	ErrNotGlobalPlatform = errors.New("not a globalplatform OID")
+)
+
+// CPLCDate is a date in the CPLC data, encoded as the last digit of the year followed by the day of the year,
+// in BCD format.
+type CPLCDate uint16
+
+// Time returns the date in the latest decade not after reference.
+func (d CPLCDate) Time(reference time.Time) (time.Time, error) {
+	digits := fmt.Sprintf("%04X", uint16(d))
+	var yearDigit, day int
+	if _, err := fmt.Sscanf(digits, "%1d%3d", &yearDigit, &day); err != nil || day < 1 || day > 366 {
+		return time.Time{}, ErrInvalidCPLCDate
+	}
+
+	year := reference.Year() - reference.Year()%10 + yearDigit
+	if year > reference.Year() {
+		year -= 10
+	}
+
+	return time.Date(year, time.January, day, 0, 0, 0, 0, time.UTC), nil
+}
+
+// CPLC is the Card Production Life Cycle data. The fields are in the order they are encoded.
+type CPLC struct {
+	ICFabricator                uint16
+	ICType                      uint16
+	OperatingSystemID           uint16
+	OperatingSystemReleaseDate  CPLCDate
+	OperatingSystemReleaseLevel uint16
+	ICFabricationDate           CPLCDate
+	ICSerialNumber              uint32
+	ICBatchIdentifier           uint16
+	ICModuleFabricator          uint16
+	ICModulePackagingDate       CPLCDate
+	ICCManufacturer             uint16
+	ICEmbeddingDate             CPLCDate
+	ICPrePersonalizer           uint16
+	ICPrePersonalizationDate    CPLCDate
+	ICPrePersonalizationEquipID uint32
+	ICPersonalizer              uint16
+	ICPersonalizationDate       CPLCDate
+	ICPersonalizationEquipID    uint32
+}
+
+// ParseCPLC parses the response of GET DATA for the CPLC, with or without the 9F7F tag.
+func ParseCPLC(data []byte) (*CPLC, error) {
+	if cplc, err := apdu.FindTag(data, TagCPLC); err == nil {
+		data = cplc
+	}
+
+	if len(data) != cplcLength {
+		return nil, ErrInvalidCPLC
+	}
+
+	c := &CPLC{}
+	if err := binary.Read(bytes.NewReader(data), binary.BigEndian, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// SecureChannelProtocol is a secure channel protocol supported by the card, with its implementation options.
+type SecureChannelProtocol struct {
+	Version uint8
+	Options uint8
+}
+
+// CardRecognitionData describes the globalplatform version and the secure channel protocols of the card.
+type CardRecognitionData struct {
+	// GlobalPlatformVersion is the version of the globalplatform specifications, like "2.2.1".
+	GlobalPlatformVersion string
+	// CardIdentificationScheme is the OID of the card identification scheme, empty if not returned by the card.
+	CardIdentificationScheme string
+	SecureChannelProtocols   []*SecureChannelProtocol
+}
+
+// SupportsSCP returns true if the card supports the secure channel protocol with the specified version.
+func (d *CardRecognitionData) SupportsSCP(version uint8) bool {
+	for _, scp := range d.SecureChannelProtocols {
+		if scp.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseCardRecognitionData parses the response of GET DATA for the card data (tag 66).
+func ParseCardRecognitionData(data []byte) (*CardRecognitionData, error) {
+	tpl, err := apdu.FindTag(data, TagCardData, TagCardRecognitionData)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &CardRecognitionData{
+		SecureChannelProtocols: make([]*SecureChannelProtocol, 0),
+	}
+
+	version, err := findGlobalPlatformOID(tpl, 0, TagCardManagementTypeVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1.2.840.114283.2.v1.v2.v3
+	if len(version) > 1 {
+		parts := make([]string, 0, len(version)-1)
+		for _, v := range version[1:] {
+			parts = append(parts, fmt.Sprintf("%d", v))
+		}
+
+		d.GlobalPlatformVersion = strings.Join(parts, ".")
+	}
+
+	if scheme, err := apdu.FindTag(tpl, TagCardIdentificationScheme, TagOID); err == nil {
+		oid, err := decodeOID(scheme)
+		if err != nil {
+			return nil, err
+		}
+
+		d.CardIdentificationScheme = oidString(oid)
+	}
+
+	for i := 0; ; i++ {
+		scp, err := findGlobalPlatformOID(tpl, i, TagSecureChannelProtocol)
+		if _, ok := err.(*apdu.ErrTagNotFound); ok {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		// 1.2.840.114283.4.scp.options
+		if len(scp) != 3 {
+			return nil, ErrInvalidOID
+		}
+
+		d.SecureChannelProtocols = append(d.SecureChannelProtocols, &SecureChannelProtocol{
+			Version: uint8(scp[1]),
+			Options: uint8(scp[2]),
+		})
+	}
+
+	return d, nil
+}
+
+// findGlobalPlatformOID returns the n-th OID in the specified tag, without the globalplatform prefix.
+func findGlobalPlatformOID(tpl []byte, n int, tag apdu.Tag) ([]int, error) {
+	value, err := apdu.FindTagN(tpl, n, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := apdu.FindTag(value, TagOID)
+	if err != nil {
+		return nil, err
+	}
+
+	oid, err := decodeOID(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(oid) < len(globalPlatformOID) {
+		return nil, ErrNotGlobalPlatform
+	}
+
+	for i, v := range globalPlatformOID {
+		if oid[i] != v {
+			return nil, ErrNotGlobalPlatform
+		}
+	}
+
+	return oid[len(globalPlatformOID):], nil
+}
+
+func decodeOID(data []byte) ([]int, error) {
+	if len(data) == 0 || data[len(data)-1]&0x80 != 0 {
+		return nil, ErrInvalidOID
+	}
+
+	oid := []int{int(data[0]) / 40, int(data[0]) % 40}
+	value := 0
+	for _, b := range data[1:] {
+		value = value<<7 | int(b&0x7F)
+		if b&0x80 == 0 {
+			oid = append(oid, value)
+			value = 0
+		}
+	}
+
+	return oid, nil
+}
+
+func oidString(oid []int) string {
+	parts := make([]string, 0, len(oid))
+	for _, v := range oid {
+		parts = append(parts, fmt.Sprintf("%d", v))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// KeyComponent is the type and length of a key component.
+type KeyComponent struct {
+	Type   uint8
+	Length int
+}
+
+// KeyInfo describes a key of the security domain.
+type KeyInfo struct {
+	ID         uint8
+	Version    uint8
+	Components []*KeyComponent
+}
+
+// ParseKeyInformation parses the Key Information Template returned by GET DATA (tag E0).
+// Both the basic and the extended key information formats are supported.
+func ParseKeyInformation(data []byte) ([]*KeyInfo, error) {
+	tpl, err := apdu.FindTag(data, TagKeyInformationTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*KeyInfo, 0)
+	for i := 0; ; i++ {
+		raw, err := apdu.FindTagN(tpl, i, TagKeyInformationData)
+		if _, ok := err.(*apdu.ErrTagNotFound); ok {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		key, err := parseKeyInfo(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func parseKeyInfo(data []byte) (*KeyInfo, error) {
+	if len(data) < 4 {
+		return nil, ErrInvalidKeyInfo
+	}
+
+	key := &KeyInfo{
+		ID:         data[0],
+		Version:    data[1],
+		Components: make([]*KeyComponent, 0),
+	}
+
+	data = data[2:]
+	if data[0] != keyComponentExtend {
+		if len(data)%2 != 0 {
+			return nil, ErrInvalidKeyInfo
+		}
+
+		for i := 0; i < len(data); i += 2 {
+			key.Components = append(key.Components, &KeyComponent{Type: data[i], Length: int(data[i+1])})
+		}
+
+		return key, nil
+	}
+
+	// extended format, the key usage and key access following the components are ignored
+	for len(data) > 0 && data[0] == keyComponentExtend {
+		if len(data) < 4 {
+			return nil, ErrInvalidKeyInfo
+		}
+
+		key.Components = append(key.Components, &KeyComponent{
+			Type:   data[1],
+			Length: int(binary.BigEndian.Uint16(data[2:4])),
+		})
+
+		data = data[4:]
+	}
+
+	return key, nil
+}
+
+// KeyVersions returns the distinct key versions in keys, in the order they appear.
+func KeyVersions(keys []*KeyInfo) []uint8 {
+	versions := make([]uint8, 0)
+	seen := make(map[uint8]bool)
+	for _, k := range keys {
+		if !seen[k.Version] {
+			seen[k.Version] = true
+			versions = append(versions, k.Version)
+		}
+	}
+
+	return versions
+}
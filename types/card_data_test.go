@@ -0,0 +1,58 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCPLC(t *testing.T) {
+	data := hexutils.HexToBytes("9F7F2A4790517940327203006572500C2D3E4F00010203040505060707080809090A0B0C0D0E0E0F0F10111213")
+	cplc, err := ParseCPLC(data)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x4790), cplc.ICFabricator)
+	assert.Equal(t, uint16(0x5179), cplc.ICType)
+	assert.Equal(t, uint16(0x4032), cplc.OperatingSystemID)
+	assert.Equal(t, CPLCDate(0x7203), cplc.OperatingSystemReleaseDate)
+	assert.Equal(t, uint32(0x0C2D3E4F), cplc.ICSerialNumber)
+	assert.Equal(t, uint32(0x10111213), cplc.ICPersonalizationEquipID)
+
+	date, err := cplc.OperatingSystemReleaseDate.Time(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2017, time.July, 22, 0, 0, 0, 0, time.UTC), date)
+
+	_, err = CPLCDate(0x70A0).Time(time.Now())
+	assert.Equal(t, ErrInvalidCPLCDate, err)
+
+	_, err = ParseCPLC(data[3:20])
+	assert.Equal(t, ErrInvalidCPLC, err)
+}
+
+func TestParseCardRecognitionData(t *testing.T) {
+	data := hexutils.HexToBytes("663E733C06072A864886FC6B01600C060A2A864886FC6B02020201630906072A864886FC6B03640B06092A864886FC6B040215640B06092A864886FC6B040310")
+	d, err := ParseCardRecognitionData(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.2.1", d.GlobalPlatformVersion)
+	assert.Equal(t, "1.2.840.114283.3", d.CardIdentificationScheme)
+	assert.Equal(t, []*SecureChannelProtocol{{Version: 0x02, Options: 0x15}, {Version: 0x03, Options: 0x10}}, d.SecureChannelProtocols)
+	assert.True(t, d.SupportsSCP(0x03))
+	assert.False(t, d.SupportsSCP(0x01))
+
+	_, err = ParseCardRecognitionData(hexutils.HexToBytes("6609730760050603550403"))
+	assert.Equal(t, ErrNotGlobalPlatform, err)
+}
+
+func TestParseKeyInformation(t *testing.T) {
+	data := hexutils.HexToBytes("E01CC00401018010C00402018010C00403018010C00A0130FF880010010001")
+	keys, err := ParseKeyInformation(data)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 4)
+	assert.Equal(t, &KeyInfo{ID: 0x02, Version: 0x01, Components: []*KeyComponent{{Type: 0x80, Length: 16}}}, keys[1])
+	assert.Equal(t, &KeyInfo{ID: 0x01, Version: 0x30, Components: []*KeyComponent{{Type: 0x88, Length: 16}}}, keys[3])
+	assert.Equal(t, []uint8{0x01, 0x30}, KeyVersions(keys))
+
+	_, err = ParseKeyInformation(hexutils.HexToBytes("E004C0020101"))
+	assert.Equal(t, ErrInvalidKeyInfo, err)
+}
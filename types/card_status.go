@@ -47,13 +47,35 @@ func (e *ErrInvalidLifeCycleValue) Error() string {
 }
 
 type CardStatus struct {
-	lc lifeCycle
+	lc         lifeCycle
+	aid        []byte
+	privileges []byte
 }
 
 func (cs *CardStatus) LifeCycle() string {
 	return cs.lc.String()
 }
 
+// State returns the raw card life cycle state.
+func (cs *CardStatus) State() byte {
+	return byte(cs.lc)
+}
+
+// Locked returns true if the card is in the CARD_LOCKED state.
+func (cs *CardStatus) Locked() bool {
+	return cs.lc == LifeCycleCardLocked
+}
+
+// AID returns the AID of the issuer security domain, nil if not returned by the card.
+func (cs *CardStatus) AID() []byte {
+	return cs.aid
+}
+
+// Privileges returns the raw privileges of the issuer security domain, nil if not returned by the card.
+func (cs *CardStatus) Privileges() []byte {
+	return cs.privileges
+}
+
 func ParseCardStatus(data []byte) (*CardStatus, error) {
 	tpl, err := apdu.FindTag(data, TagGetStatusTemplate)
 	if err != nil {
@@ -69,5 +91,14 @@ func ParseCardStatus(data []byte) (*CardStatus, error) {
 		return nil, &ErrInvalidLifeCycleValue{lc}
 	}
 
-	return &CardStatus{lifeCycle(lc[0])}, nil
+	status := &CardStatus{lc: lifeCycle(lc[0])}
+	if aid, err := apdu.FindTag(tpl, TagGetStatusAID); err == nil {
+		status.aid = aid
+	}
+
+	if privileges, err := apdu.FindTag(tpl, TagGetStatusPrivileges); err == nil {
+		status.privileges = privileges
+	}
+
+	return status, nil
 }
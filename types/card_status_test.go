@@ -0,0 +1,28 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCardStatus(t *testing.T) {
+	data := hexutils.HexToBytes("E3134F08A0000001510000009F70017FC5039EFE80")
+	status, err := ParseCardStatus(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "CARD_LOCKED", status.LifeCycle())
+	assert.Equal(t, byte(0x7F), status.State())
+	assert.True(t, status.Locked())
+	assert.Equal(t, "A000000151000000", hexutils.BytesToHex(status.AID()))
+	assert.Equal(t, []byte{0x9E, 0xFE, 0x80}, status.Privileges())
+
+	status, err = ParseCardStatus(hexutils.HexToBytes("E3049F70010F"))
+	assert.NoError(t, err)
+	assert.Equal(t, "SECURED", status.LifeCycle())
+	assert.False(t, status.Locked())
+	assert.Nil(t, status.Privileges())
+
+	_, err = ParseCardStatus(hexutils.HexToBytes("E3059F7002070F"))
+	assert.IsType(t, &ErrInvalidLifeCycleValue{}, err)
+}
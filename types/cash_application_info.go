@@ -1,6 +1,31 @@
 package types
 
-import "github.com/status-im/keycard-go/apdu"
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/apdu"
+)
+
+const TagCashPublicDataTemplate = uint8(0xA0)
+
+var (
+	TagCashPublicDataChainID = apdu.Tag{0x80}
+	TagCashPublicDataToken   = apdu.Tag{0x81}
+	TagCashPublicDataLabel   = apdu.Tag{0x82}
+)
+
+var (
+	ErrInvalidCashPublicData = errors.New("invalid cash public data")
+	ErrCashPublicDataTooLong = errors.New("cash public data too long")
+)
+
+// maxCashPublicDataLength is the longest public data fitting in the install parameters.
+const maxCashPublicDataLength = 0x7F
 
 type CashApplicationInfo struct {
 	Installed  bool
@@ -9,6 +34,123 @@ type CashApplicationInfo struct {
 	Version    []byte
 }
 
+// Address returns the Ethereum address of the cash key.
+func (i *CashApplicationInfo) Address() (common.Address, error) {
+	pubKey, err := crypto.UnmarshalPubkey(i.PublicKey)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// ParsePublicData parses the public data set when the applet was installed.
+func (i *CashApplicationInfo) ParsePublicData() (*CashPublicData, error) {
+	return ParseCashPublicData(i.PublicData)
+}
+
+// CashPublicData describes what the cash key is used for. It's set when installing the applet.
+// The Cash applet stores the public data as it is, without defining its format: the format of
+// CashPublicData is a convention of this library. The fields are encoded in a template with tag
+// TagCashPublicDataTemplate, so that the public data set by other tools is rejected instead of misread.
+type CashPublicData struct {
+	ChainID uint64
+	// Token is the ERC-20 token contract, the zero address for the native currency.
+	Token common.Address
+	Label string
+}
+
+// Encode returns the public data template. Zero values are omitted, and no data is returned if all the
+// values are zero.
+func (d *CashPublicData) Encode() ([]byte, error) {
+	fields := make([]byte, 0)
+	if d.ChainID != 0 {
+		fields = appendCashTLV(fields, TagCashPublicDataChainID, new(big.Int).SetUint64(d.ChainID).Bytes())
+	}
+
+	if d.Token != (common.Address{}) {
+		fields = appendCashTLV(fields, TagCashPublicDataToken, d.Token.Bytes())
+	}
+
+	if d.Label != "" {
+		fields = appendCashTLV(fields, TagCashPublicDataLabel, []byte(d.Label))
+	}
+
+	if len(fields) == 0 {
+		return fields, nil
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(TagCashPublicDataTemplate)
+	apdu.WriteLength(buf, uint32(len(fields)))
+	buf.Write(fields)
+
+	if buf.Len() > maxCashPublicDataLength {
+		return nil, ErrCashPublicDataTooLong
+	}
+
+	return buf.Bytes(), nil
+}
+
+func appendCashTLV(data []byte, tag apdu.Tag, value []byte) []byte {
+	data = append(data, tag...)
+	data = append(data, byte(len(value)))
+	return append(data, value...)
+}
+
+// ParseCashPublicData parses public data encoded with CashPublicData.Encode. Empty data returns empty CashPublicData.
+// ErrInvalidCashPublicData is returned if data is not a CashPublicData template.
+func ParseCashPublicData(data []byte) (*CashPublicData, error) {
+	d := &CashPublicData{}
+	if len(data) == 0 {
+		return d, nil
+	}
+
+	if data[0] != TagCashPublicDataTemplate {
+		return nil, ErrInvalidCashPublicData
+	}
+
+	buf := bytes.NewBuffer(data[1:])
+	length, err := apdu.ParseLength(buf)
+	if err != nil || int(length) != buf.Len() {
+		return nil, ErrInvalidCashPublicData
+	}
+
+	data = buf.Bytes()
+
+	if chainID, err := apdu.FindTag(data, TagCashPublicDataChainID); err == nil {
+		if len(chainID) > 8 {
+			return nil, ErrInvalidCashPublicData
+		}
+
+		d.ChainID = new(big.Int).SetBytes(chainID).Uint64()
+	} else if _, ok := err.(*apdu.ErrTagNotFound); !ok {
+		return nil, err
+	}
+
+	if token, err := apdu.FindTag(data, TagCashPublicDataToken); err == nil {
+		if len(token) != common.AddressLength {
+			return nil, ErrInvalidCashPublicData
+		}
+
+		d.Token = common.BytesToAddress(token)
+	} else if _, ok := err.(*apdu.ErrTagNotFound); !ok {
+		return nil, err
+	}
+
+	if label, err := apdu.FindTag(data, TagCashPublicDataLabel); err == nil {
+		if !utf8.Valid(label) {
+			return nil, ErrInvalidCashPublicData
+		}
+
+		d.Label = string(label)
+	} else if _, ok := err.(*apdu.ErrTagNotFound); !ok {
+		return nil, err
+	}
+
+	return d, nil
+}
+
 func ParseCashApplicationInfo(data []byte) (*CashApplicationInfo, error) {
 	info := &CashApplicationInfo{}
 
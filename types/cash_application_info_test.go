@@ -0,0 +1,72 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCashPublicData(t *testing.T) {
+	d := &CashPublicData{
+		ChainID: 10,
+		Token:   common.HexToAddress("0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85"),
+		Label:   "coffee",
+	}
+
+	data, err := d.Encode()
+	assert.NoError(t, err)
+	assert.Equal(t, "A02180010A81140B2C639C533813F4AA9D7837CAF62653D097FF858206636F66666565", hexutils.BytesToHex(data))
+
+	parsed, err := ParseCashPublicData(data)
+	assert.NoError(t, err)
+	assert.Equal(t, d, parsed)
+
+	empty, err := ParseCashPublicData([]byte{})
+	assert.NoError(t, err)
+	assert.Equal(t, &CashPublicData{}, empty)
+
+	empty, err = ParseCashPublicData(hexutils.HexToBytes("A000"))
+	assert.NoError(t, err)
+	assert.Equal(t, &CashPublicData{}, empty)
+
+	data, err = (&CashPublicData{}).Encode()
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	_, err = ParseCashPublicData(hexutils.HexToBytes("A0048102AABB"))
+	assert.Equal(t, ErrInvalidCashPublicData, err)
+
+	// public data not in the format of this library
+	_, err = ParseCashPublicData(hexutils.HexToBytes("80010A"))
+	assert.Equal(t, ErrInvalidCashPublicData, err)
+
+	_, err = ParseCashPublicData(hexutils.HexToBytes("A00580010A"))
+	assert.Equal(t, ErrInvalidCashPublicData, err)
+
+	d.Label = string(make([]byte, 110))
+	_, err = d.Encode()
+	assert.Equal(t, ErrCashPublicDataTooLong, err)
+}
+
+func TestCashApplicationInfo(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	pubKey := crypto.FromECDSAPub(&key.PublicKey)
+
+	data := append([]byte{0xA4, byte(2 + len(pubKey) + 7 + 4), 0x80, byte(len(pubKey))}, pubKey...)
+	data = append(data, 0x82, 0x05, 0xA0, 0x03, 0x80, 0x01, 0x01, 0x02, 0x02, 0x01, 0x00)
+
+	info, err := ParseCashApplicationInfo(data)
+	assert.NoError(t, err)
+
+	address, err := info.Address()
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), address)
+
+	publicData, err := info.ParsePublicData()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), publicData.ChainID)
+}
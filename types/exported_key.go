@@ -8,8 +8,11 @@ import (
 )
 
 var (
-	TagExportKeyTemplate = uint8(0xA1)
-	TagExportKeyPublic   = uint8(0x81)
+	TagExportKeyTemplate  = uint8(0xA1)
+	TagExportKeyPublicKey = uint8(0x80)
+	// TagExportKeyPublic is the tag of the exported private key, the name is kept for compatibility.
+	TagExportKeyPublic    = uint8(0x81)
+	TagExportKeyChainCode = uint8(0x82)
 )
 
 func ParseExportKeyResponse(data []byte) ([]byte, []byte, error) {
@@ -33,6 +36,27 @@ func ParseExportKeyResponse(data []byte) ([]byte, []byte, error) {
 	return privKey, pubKey, nil
 }
 
+// ParseExportExtendedKeyResponse parses the response of an extended public key export,
+// returning the public key and the chain code.
+func ParseExportExtendedKeyResponse(data []byte) ([]byte, []byte, error) {
+	tpl, err := apdu.FindTag(data, apdu.Tag{TagExportKeyTemplate})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pubKey, err := apdu.FindTag(tpl, apdu.Tag{TagExportKeyPublicKey})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chainCode, err := apdu.FindTag(tpl, apdu.Tag{TagExportKeyChainCode})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pubKey, chainCode, nil
+}
+
 func tryFindTag(tpl []byte, tags ...apdu.Tag) []byte {
 	data, err := apdu.FindTag(tpl, tags...)
 	if err != nil {
@@ -3,19 +3,78 @@ package types
 import (
 	"bytes"
 	"container/list"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"math"
+	"sort"
+	"time"
 
 	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/derivationpath"
 )
 
+const (
+	MetadataVersion1 = 1
+	MetadataVersion2 = 2
+
+	maxNameLengthV1  = 20
+	maxStringLength  = 0xFF
+	timestampLength  = 4
+	indexLength      = 4
+	versionBitsShift = 5
+
+	// maxIndexes is the maximum number of account indexes expanded from the ranges of a metadata.
+	maxIndexes = 0x10000
+)
+
+var ErrTooManyIndexes = errors.New("metadata contains too many account indexes")
+
+// AccountType describes what an account stored in version 2 metadata is used for.
+type AccountType uint8
+
+const (
+	AccountTypeEthereum AccountType = iota + 1
+	AccountTypeBitcoin
+	AccountTypeChat
+)
+
+func (t AccountType) String() string {
+	switch t {
+	case AccountTypeEthereum:
+		return "ethereum"
+	case AccountTypeBitcoin:
+		return "bitcoin"
+	case AccountTypeChat:
+		return "chat"
+	default:
+		return "unknown"
+	}
+}
+
+// Account is an account stored in version 2 metadata.
+type Account struct {
+	Type  AccountType
+	Path  []uint32
+	Label string
+}
+
 type Metadata struct {
-	name  string
-	paths *list.List
+	version  uint8
+	name     string
+	paths    *list.List
+	created  time.Time
+	accounts []*Account
 }
 
 func EmptyMetadata() *Metadata {
-	return &Metadata{"", list.New()}
+	return &Metadata{version: MetadataVersion1, paths: list.New()}
+}
+
+// EmptyMetadataV2 returns an empty version 2 Metadata created at the specified time.
+func EmptyMetadataV2(created time.Time) *Metadata {
+	return &Metadata{version: MetadataVersion2, paths: list.New(), created: created}
 }
 
 func NewMetadata(name string, paths []uint32) (*Metadata, error) {
@@ -32,6 +91,23 @@ func NewMetadata(name string, paths []uint32) (*Metadata, error) {
 	return m, nil
 }
 
+// NewMetadataV2 returns a version 2 Metadata with the specified name, creation time and accounts.
+func NewMetadataV2(name string, created time.Time, accounts []*Account) (*Metadata, error) {
+	m := EmptyMetadataV2(created)
+
+	if err := m.SetName(name); err != nil {
+		return nil, err
+	}
+
+	for _, a := range accounts {
+		if err := m.AddAccount(a); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
 func ParseMetadata(data []byte) (*Metadata, error) {
 	buf := bytes.NewBuffer(data)
 	header, err := buf.ReadByte()
@@ -40,38 +116,213 @@ func ParseMetadata(data []byte) (*Metadata, error) {
 		return nil, err
 	}
 
-	version := header >> 5
+	version := header >> versionBitsShift
 
-	if version != 1 {
+	switch version {
+	case MetadataVersion1:
+		return parseMetadataV1(header, buf)
+	case MetadataVersion2:
+		return parseMetadataV2(buf)
+	default:
 		return nil, errors.New("invalid version")
 	}
+}
 
+func parseMetadataV1(header byte, buf *bytes.Buffer) (*Metadata, error) {
 	namelen := int(header & 0x1f)
 	cardName := string(buf.Next(namelen))
 
 	list := list.New()
+	remaining := maxIndexes
+
+	err := parseRanges(buf, -1, &remaining, apdu.ParseLength, func(i uint32) {
+		insertOrderedNoDups(list, i)
+	})
 
-	for {
-		start, err := apdu.ParseLength(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metadata{version: MetadataVersion1, name: cardName, paths: list}, nil
+}
+
+func parseMetadataV2(buf *bytes.Buffer) (*Metadata, error) {
+	cardName, err := readString(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if buf.Len() < timestampLength {
+		return nil, errors.New("missing creation timestamp")
+	}
+
+	m := EmptyMetadataV2(time.Time{})
+	m.name = cardName
+
+	if ts := binary.BigEndian.Uint32(buf.Next(timestampLength)); ts != 0 {
+		m.created = time.Unix(int64(ts), 0).UTC()
+	}
+
+	remaining := maxIndexes
+	for buf.Len() > 0 {
+		if err := m.parseAccountGroup(buf, &remaining); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
 
-		if err == io.EOF {
+// parseAccountGroup parses a set of accounts of the same type, sharing the same parent path.
+func (m *Metadata) parseAccountGroup(buf *bytes.Buffer, remaining *int) error {
+	typ, err := buf.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	parentStr, err := readString(buf)
+	if err != nil {
+		return err
+	}
+
+	startingPoint, parent, err := derivationpath.Decode(parentStr)
+	if err != nil {
+		return err
+	}
+
+	if startingPoint != derivationpath.StartingPointMaster {
+		return fmt.Errorf("account path must be absolute, got %s", parentStr)
+	}
+
+	rangesCount, err := apdu.ParseLength(buf)
+	if err != nil {
+		return err
+	}
+
+	group := make(map[uint32]*Account)
+	err = parseRanges(buf, int(rangesCount), remaining, readIndex, func(i uint32) {
+		group[i] = &Account{
+			Type: AccountType(typ),
+			Path: appendIndex(parent, i),
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	labelsCount, err := apdu.ParseLength(buf)
+	if err != nil {
+		return err
+	}
+
+	for j := uint32(0); j < labelsCount; j++ {
+		index, err := readIndex(buf)
+		if err != nil {
+			return err
+		}
+
+		label, err := readString(buf)
+		if err != nil {
+			return err
+		}
+
+		a, ok := group[index]
+		if !ok {
+			return fmt.Errorf("label for unknown account index %d", index)
+		}
+
+		a.Label = label
+	}
+
+	for _, a := range group {
+		if err := m.AddAccount(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseRanges parses count (start, length) pairs, or all the pairs until EOF if count is negative,
+// calling f for each index. The start of each range is read with parseStart. remaining is the number
+// of indexes that can still be expanded, it's decreased by the number of indexes parsed.
+func parseRanges(buf *bytes.Buffer, count int, remaining *int, parseStart func(*bytes.Buffer) (uint32, error), f func(uint32)) error {
+	for n := 0; count < 0 || n < count; n++ {
+		start, err := parseStart(buf)
+
+		if err == io.EOF && count < 0 {
 			break
 		} else if err != nil {
-			return nil, err
+			return err
 		}
 
-		count, err := apdu.ParseLength(buf)
+		length, err := apdu.ParseLength(buf)
 
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		if start > math.MaxUint32-length {
+			return fmt.Errorf("account range %d+%d overflows", start, length)
 		}
 
-		for i := start; i <= (start + count); i++ {
-			insertOrderedNoDups(list, i)
+		if uint64(length) >= uint64(*remaining) {
+			return ErrTooManyIndexes
 		}
+
+		*remaining -= int(length) + 1
+
+		for i := uint32(0); i <= length; i++ {
+			f(start + i)
+		}
+	}
+
+	return nil
+}
+
+// readIndex reads an account index of version 2 metadata, stored as 4 bytes big endian
+// since the indexes of full paths can be hardened.
+func readIndex(buf *bytes.Buffer) (uint32, error) {
+	if buf.Len() == 0 {
+		return 0, io.EOF
+	}
+
+	if buf.Len() < indexLength {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	return binary.BigEndian.Uint32(buf.Next(indexLength)), nil
+}
+
+func writeIndex(buf *bytes.Buffer, index uint32) {
+	b := make([]byte, indexLength)
+	binary.BigEndian.PutUint32(b, index)
+	buf.Write(b)
+}
+
+func readString(buf *bytes.Buffer) (string, error) {
+	length, err := buf.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	if buf.Len() < int(length) {
+		return "", io.ErrUnexpectedEOF
 	}
 
-	return &Metadata{cardName, list}, nil
+	return string(buf.Next(int(length))), nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte(byte(len(s)))
+	buf.WriteString(s)
+}
+
+func appendIndex(parent []uint32, index uint32) []uint32 {
+	path := make([]uint32, len(parent), len(parent)+1)
+	copy(path, parent)
+	return append(path, index)
 }
 
 func insertOrderedNoDups(list *list.List, num uint32) {
@@ -96,19 +347,111 @@ func insertOrderedNoDups(list *list.List, num uint32) {
 	}
 }
 
+// Version returns the metadata format version.
+func (m *Metadata) Version() uint8 {
+	return m.version
+}
+
 func (m *Metadata) Name() string {
 	return m.name
 }
 
 func (m *Metadata) SetName(name string) error {
-	if len(name) > 20 {
+	if m.version == MetadataVersion1 && len(name) > maxNameLengthV1 {
 		return errors.New("name longer than 20 chars")
 	}
 
+	if len(name) > maxStringLength {
+		return errors.New("name longer than 255 bytes")
+	}
+
 	m.name = name
 	return nil
 }
 
+// Created returns the creation time of version 2 metadata.
+// It returns the zero time if unknown.
+func (m *Metadata) Created() time.Time {
+	return m.created
+}
+
+// Accounts returns the accounts of version 2 metadata,
+// ordered by type, path length and path.
+func (m *Metadata) Accounts() []*Account {
+	accounts := make([]*Account, len(m.accounts))
+	copy(accounts, m.accounts)
+	return accounts
+}
+
+// Account returns the account with the specified path, or nil if not found.
+func (m *Metadata) Account(path []uint32) *Account {
+	for _, a := range m.accounts {
+		if equalPaths(a.Path, path) {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// AddAccount adds an account to version 2 metadata.
+// If an account with the same path exists, it's replaced.
+func (m *Metadata) AddAccount(a *Account) error {
+	if m.version != MetadataVersion2 {
+		return errors.New("accounts can only be added to version 2 metadata")
+	}
+
+	if len(a.Path) == 0 {
+		return errors.New("account path cannot be empty")
+	}
+
+	if len(a.Label) > maxStringLength {
+		return errors.New("label longer than 255 bytes")
+	}
+
+	m.RemoveAccount(a.Path)
+	m.accounts = append(m.accounts, a)
+
+	sort.Slice(m.accounts, func(i, j int) bool {
+		return lessAccount(m.accounts[i], m.accounts[j])
+	})
+
+	return nil
+}
+
+// RemoveAccount removes the account with the specified path.
+func (m *Metadata) RemoveAccount(path []uint32) {
+	for i, a := range m.accounts {
+		if equalPaths(a.Path, path) {
+			m.accounts = append(m.accounts[:i], m.accounts[i+1:]...)
+			return
+		}
+	}
+}
+
+// UpgradeToV2 converts version 1 metadata to version 2.
+// Each index becomes an account of type typ, with parent as the parent path.
+func (m *Metadata) UpgradeToV2(parent []uint32, typ AccountType, created time.Time) error {
+	if m.version != MetadataVersion1 {
+		return errors.New("only version 1 metadata can be upgraded")
+	}
+
+	indexes := m.Paths()
+
+	m.version = MetadataVersion2
+	m.created = created
+	m.paths = list.New()
+
+	for _, index := range indexes {
+		if err := m.AddAccount(&Account{Type: typ, Path: appendIndex(parent, index)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Paths returns the last-level indexes stored in version 1 metadata.
 func (m *Metadata) Paths() []uint32 {
 	listlen := m.paths.Len()
 	paths := make([]uint32, listlen)
@@ -136,34 +479,128 @@ func (m *Metadata) RemovePath(path uint32) {
 }
 
 func (m *Metadata) Serialize() []byte {
+	if m.version == MetadataVersion2 {
+		return m.serializeV2()
+	}
+
 	buf := new(bytes.Buffer)
-	buf.WriteByte(0x20 | byte(len(m.name)))
+	buf.WriteByte(MetadataVersion1<<versionBitsShift | byte(len(m.name)))
 	buf.WriteString(m.name)
 
-	le := m.paths.Front()
+	for _, r := range compressRanges(m.Paths()) {
+		apdu.WriteLength(buf, r[0])
+		apdu.WriteLength(buf, r[1])
+	}
 
-	if le == nil {
-		return buf.Bytes()
+	return buf.Bytes()
+}
+
+func (m *Metadata) serializeV2() []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(MetadataVersion2 << versionBitsShift)
+	writeString(buf, m.name)
+
+	ts := make([]byte, timestampLength)
+	if !m.created.IsZero() {
+		binary.BigEndian.PutUint32(ts, uint32(m.created.Unix()))
 	}
+	buf.Write(ts)
+
+	// accounts are sorted, so accounts with the same type and parent path are contiguous.
+	for i := 0; i < len(m.accounts); {
+		first := m.accounts[i]
+		parent := first.Path[:len(first.Path)-1]
+
+		j := i
+		indexes := make([]uint32, 0)
+		labeled := make([]*Account, 0)
+		for ; j < len(m.accounts) && sameGroup(first, m.accounts[j]); j++ {
+			a := m.accounts[j]
+			indexes = append(indexes, a.Path[len(a.Path)-1])
+			if a.Label != "" {
+				labeled = append(labeled, a)
+			}
+		}
 
-	start := le.Value.(uint32)
-	len := uint32(0)
+		buf.WriteByte(byte(first.Type))
+		writeString(buf, derivationpath.Encode(parent))
+
+		ranges := compressRanges(indexes)
+		apdu.WriteLength(buf, uint32(len(ranges)))
+		for _, r := range ranges {
+			writeIndex(buf, r[0])
+			apdu.WriteLength(buf, r[1])
+		}
+
+		apdu.WriteLength(buf, uint32(len(labeled)))
+		for _, a := range labeled {
+			writeIndex(buf, a.Path[len(a.Path)-1])
+			writeString(buf, a.Label)
+		}
 
-	for le = le.Next(); le != nil; le = le.Next() {
-		w := le.Value.(uint32)
+		i = j
+	}
 
+	return buf.Bytes()
+}
+
+// compressRanges converts a sorted list of indexes to a list of (start, length) pairs,
+// where length is the number of consecutive indexes following start.
+func compressRanges(indexes []uint32) [][2]uint32 {
+	ranges := make([][2]uint32, 0)
+
+	if len(indexes) == 0 {
+		return ranges
+	}
+
+	start := indexes[0]
+	len := uint32(0)
+
+	for _, w := range indexes[1:] {
 		if w == (start + len + 1) {
 			len++
 		} else {
-			apdu.WriteLength(buf, start)
-			apdu.WriteLength(buf, len)
+			ranges = append(ranges, [2]uint32{start, len})
 			start = w
 			len = 0
 		}
 	}
 
-	apdu.WriteLength(buf, start)
-	apdu.WriteLength(buf, len)
+	return append(ranges, [2]uint32{start, len})
+}
 
-	return buf.Bytes()
+func sameGroup(a, b *Account) bool {
+	return a.Type == b.Type && equalPaths(a.Path[:len(a.Path)-1], b.Path[:len(b.Path)-1])
+}
+
+func lessAccount(a, b *Account) bool {
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+
+	if len(a.Path) != len(b.Path) {
+		return len(a.Path) < len(b.Path)
+	}
+
+	for i := range a.Path {
+		if a.Path[i] != b.Path[i] {
+			return a.Path[i] < b.Path[i]
+		}
+	}
+
+	return false
+}
+
+func equalPaths(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
 }
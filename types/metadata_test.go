@@ -1,7 +1,9 @@
 package types
 
 import (
+	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,8 +15,133 @@ func TestParseMetadata(t *testing.T) {
 	assert.Equal(t, []uint32{0x00, 0x04, 0x05, 0x06, 0x07, 0x7a28, 0x7a29}, m.Paths())
 }
 
+func TestParseMetadata_TooManyIndexes(t *testing.T) {
+	m, err := ParseMetadata([]byte{0x20, 0x00, 0x82, 0xff, 0xff})
+	assert.NoError(t, err)
+	assert.Len(t, m.Paths(), maxIndexes)
+
+	_, err = ParseMetadata([]byte{0x20, 0x00, 0x83, 0xff, 0xff, 0xff})
+	assert.Equal(t, ErrTooManyIndexes, err)
+
+	_, err = ParseMetadata([]byte{0x20, 0x00, 0x82, 0x80, 0x00, 0x82, 0x01, 0x00, 0x82, 0x80, 0x00})
+	assert.Equal(t, ErrTooManyIndexes, err)
+}
+
 func TestSerialize(t *testing.T) {
 	m, err := NewMetadata("123", []uint32{0x00, 0x04, 0x05, 0x06, 0x07, 0x7a28, 0x7a29})
 	assert.NoError(t, err)
 	assert.Equal(t, []byte{0x23, 0x31, 0x32, 0x33, 0x00, 0x00, 0x04, 0x03, 0x82, 0x7a, 0x28, 0x01}, m.Serialize())
 }
+
+func TestMetadataV2_SerializeAndParse(t *testing.T) {
+	created := time.Unix(1700000000, 0).UTC()
+	walletRoot := []uint32{0x8000002c, 0x8000003c, 0x80000000, 0}
+	btcRoot := []uint32{0x80000054, 0x80000000, 0x80000000, 0}
+
+	accounts := []*Account{
+		{Type: AccountTypeEthereum, Path: appendIndex(walletRoot, 1)},
+		{Type: AccountTypeEthereum, Path: appendIndex(walletRoot, 0), Label: "main"},
+		{Type: AccountTypeEthereum, Path: appendIndex(walletRoot, 2)},
+		{Type: AccountTypeEthereum, Path: appendIndex(walletRoot, 9), Label: "savings"},
+		{Type: AccountTypeBitcoin, Path: appendIndex(btcRoot, 0)},
+		{Type: AccountTypeChat, Path: []uint32{0x8000002b, 0x8000003c, 0x8000062d, 0x80000000, 0}},
+	}
+
+	m, err := NewMetadataV2("a card name longer than twenty chars", created, accounts)
+	assert.NoError(t, err)
+
+	data := m.Serialize()
+	assert.Equal(t, byte(0x40), data[0])
+
+	parsed, err := ParseMetadata(data)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(MetadataVersion2), parsed.Version())
+	assert.Equal(t, "a card name longer than twenty chars", parsed.Name())
+	assert.Equal(t, created, parsed.Created())
+	assert.Equal(t, m.Accounts(), parsed.Accounts())
+
+	// ordered by type and path
+	assert.Equal(t, "main", parsed.Accounts()[0].Label)
+	assert.Equal(t, appendIndex(walletRoot, 9), parsed.Accounts()[3].Path)
+	assert.Equal(t, AccountTypeBitcoin, parsed.Accounts()[4].Type)
+	assert.Equal(t, AccountTypeChat, parsed.Accounts()[5].Type)
+
+	assert.Equal(t, data, parsed.Serialize())
+}
+
+func TestMetadataV2_SerializeCompressesRanges(t *testing.T) {
+	m := EmptyMetadataV2(time.Time{})
+	for i := uint32(0); i < 20; i++ {
+		assert.NoError(t, m.AddAccount(&Account{Type: AccountTypeEthereum, Path: []uint32{0x8000002c, i}}))
+	}
+
+	expected := []byte{
+		0x40,
+		0x00,                   // name
+		0x00, 0x00, 0x00, 0x00, // created
+		0x01,                           // type
+		0x05, 'm', '/', '4', '4', 0x27, // parent path
+		0x01,                         // 1 range
+		0x00, 0x00, 0x00, 0x00, 0x13, // 0-19
+		0x00, // no labels
+	}
+	assert.Equal(t, expected, m.Serialize())
+}
+
+func TestMetadataV2_HardenedIndexes(t *testing.T) {
+	accounts := []*Account{
+		{Type: AccountTypeEthereum, Path: []uint32{0x8000002c, 0x8000003c, 0x80000000}, Label: "hardened"},
+		{Type: AccountTypeEthereum, Path: []uint32{0x8000002c, 0x8000003c, 0x80000001}},
+		{Type: AccountTypeEthereum, Path: []uint32{0x8000002c, 0x8000003c, 0xffffffff}},
+		{Type: AccountTypeBitcoin, Path: []uint32{0x80000054, 0x80000000, 0x80000000, 0, 0x1000000}},
+	}
+
+	m, err := NewMetadataV2("card", time.Time{}, accounts)
+	assert.NoError(t, err)
+
+	parsed, err := ParseMetadata(m.Serialize())
+	assert.NoError(t, err)
+	assert.Equal(t, m.Accounts(), parsed.Accounts())
+	assert.Equal(t, "hardened", parsed.Account([]uint32{0x8000002c, 0x8000003c, 0x80000000}).Label)
+
+	_, err = ParseMetadata([]byte{0x40, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 'm', 0x01, 0x80, 0x00})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestMetadataV2_AddRemoveAccount(t *testing.T) {
+	m := EmptyMetadataV2(time.Time{})
+	path := []uint32{0x8000002c, 0x8000003c, 0x80000000, 0, 3}
+
+	assert.NoError(t, m.AddAccount(&Account{Type: AccountTypeEthereum, Path: path}))
+	assert.NoError(t, m.AddAccount(&Account{Type: AccountTypeEthereum, Path: path, Label: "renamed"}))
+	assert.Len(t, m.Accounts(), 1)
+	assert.Equal(t, "renamed", m.Account(path).Label)
+
+	m.RemoveAccount(path)
+	assert.Len(t, m.Accounts(), 0)
+	assert.Nil(t, m.Account(path))
+
+	assert.Error(t, m.AddAccount(&Account{Type: AccountTypeEthereum}))
+	assert.Error(t, EmptyMetadata().AddAccount(&Account{Type: AccountTypeEthereum, Path: path}))
+}
+
+func TestMetadata_UpgradeToV2(t *testing.T) {
+	m, err := NewMetadata("123", []uint32{0, 1, 5})
+	assert.NoError(t, err)
+
+	created := time.Unix(1700000000, 0).UTC()
+	walletRoot := []uint32{0x8000002c, 0x8000003c, 0x80000000, 0}
+	assert.NoError(t, m.UpgradeToV2(walletRoot, AccountTypeEthereum, created))
+
+	assert.Equal(t, uint8(MetadataVersion2), m.Version())
+	assert.Equal(t, []uint32{}, m.Paths())
+	assert.Len(t, m.Accounts(), 3)
+	assert.Equal(t, appendIndex(walletRoot, 5), m.Accounts()[2].Path)
+
+	parsed, err := ParseMetadata(m.Serialize())
+	assert.NoError(t, err)
+	assert.Equal(t, "123", parsed.Name())
+	assert.Equal(t, m.Accounts(), parsed.Accounts())
+
+	assert.Error(t, m.UpgradeToV2(walletRoot, AccountTypeEthereum, created))
+}
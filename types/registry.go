@@ -0,0 +1,151 @@
+package types
+
+import (
+	"errors"
+
+	"github.com/status-im/keycard-go/apdu"
+)
+
+var (
+	TagGetStatusAID               = apdu.Tag{0x4F}
+	TagGetStatusPrivileges        = apdu.Tag{0xC5}
+	TagGetStatusVersion           = apdu.Tag{0xCE}
+	TagGetStatusModuleAID         = apdu.Tag{0x84}
+	TagGetStatusSecurityDomainAID = apdu.Tag{0xCC}
+)
+
+var ErrInvalidRegistryEntry = errors.New("registry entry must contain an AID and a 1 byte life cycle")
+
+// ApplicationLifeCycle is the life cycle state of an application or executable load file.
+type ApplicationLifeCycle byte
+
+const (
+	ApplicationLifeCycleLoaded       ApplicationLifeCycle = 0x01
+	ApplicationLifeCycleInstalled    ApplicationLifeCycle = 0x03
+	ApplicationLifeCycleSelectable   ApplicationLifeCycle = 0x07
+	ApplicationLifeCyclePersonalized ApplicationLifeCycle = 0x0F
+	applicationLifeCycleLockedBit    ApplicationLifeCycle = 0x80
+)
+
+// Locked returns true if the application is locked.
+func (lc ApplicationLifeCycle) Locked() bool {
+	return lc&applicationLifeCycleLockedBit != 0
+}
+
+func (lc ApplicationLifeCycle) String() string {
+	if lc.Locked() {
+		return "LOCKED"
+	}
+
+	switch lc {
+	case ApplicationLifeCycleLoaded:
+		return "LOADED"
+	case ApplicationLifeCycleInstalled:
+		return "INSTALLED"
+	case ApplicationLifeCycleSelectable:
+		return "SELECTABLE"
+	case ApplicationLifeCyclePersonalized:
+		return "PERSONALIZED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ApplicationEntry is an application or security domain as returned by GET STATUS.
+type ApplicationEntry struct {
+	AID               []byte
+	LifeCycle         ApplicationLifeCycle
+	Privileges        []byte
+	SecurityDomainAID []byte
+}
+
+// LoadFileEntry is an executable load file as returned by GET STATUS.
+// Modules is only set when listing executable load files and modules.
+type LoadFileEntry struct {
+	AID               []byte
+	LifeCycle         ApplicationLifeCycle
+	Version           []byte
+	Modules           [][]byte
+	SecurityDomainAID []byte
+}
+
+// ParseApplicationEntries parses the GET STATUS response listing applications.
+func ParseApplicationEntries(data []byte) ([]*ApplicationEntry, error) {
+	entries := make([]*ApplicationEntry, 0)
+	err := eachRegistryTemplate(data, func(tpl []byte, aid []byte, lc ApplicationLifeCycle) {
+		entry := &ApplicationEntry{
+			AID:       aid,
+			LifeCycle: lc,
+		}
+
+		if privileges, err := apdu.FindTag(tpl, TagGetStatusPrivileges); err == nil {
+			entry.Privileges = privileges
+		}
+
+		if sdAID, err := apdu.FindTag(tpl, TagGetStatusSecurityDomainAID); err == nil {
+			entry.SecurityDomainAID = sdAID
+		}
+
+		entries = append(entries, entry)
+	})
+
+	return entries, err
+}
+
+// ParseLoadFileEntries parses the GET STATUS response listing executable load files, with or without modules.
+func ParseLoadFileEntries(data []byte) ([]*LoadFileEntry, error) {
+	entries := make([]*LoadFileEntry, 0)
+	err := eachRegistryTemplate(data, func(tpl []byte, aid []byte, lc ApplicationLifeCycle) {
+		entry := &LoadFileEntry{
+			AID:       aid,
+			LifeCycle: lc,
+			Modules:   make([][]byte, 0),
+		}
+
+		if version, err := apdu.FindTag(tpl, TagGetStatusVersion); err == nil {
+			entry.Version = version
+		}
+
+		if sdAID, err := apdu.FindTag(tpl, TagGetStatusSecurityDomainAID); err == nil {
+			entry.SecurityDomainAID = sdAID
+		}
+
+		for i := 0; ; i++ {
+			module, err := apdu.FindTagN(tpl, i, TagGetStatusModuleAID)
+			if err != nil {
+				break
+			}
+
+			entry.Modules = append(entry.Modules, module)
+		}
+
+		entries = append(entries, entry)
+	})
+
+	return entries, err
+}
+
+func eachRegistryTemplate(data []byte, fn func(tpl []byte, aid []byte, lc ApplicationLifeCycle)) error {
+	for i := 0; ; i++ {
+		tpl, err := apdu.FindTagN(data, i, TagGetStatusTemplate)
+		if err != nil {
+			if _, ok := err.(*apdu.ErrTagNotFound); ok {
+				return nil
+			}
+
+			return err
+		}
+
+		aid, err := apdu.FindTag(tpl, TagGetStatusAID)
+		if err != nil {
+			return ErrInvalidRegistryEntry
+		}
+
+		lc, err := apdu.FindTag(tpl, TagGetStatusLifeCycleState)
+		if err != nil || len(lc) != 1 {
+			return ErrInvalidRegistryEntry
+		}
+
+		fn(tpl, aid, ApplicationLifeCycle(lc[0]))
+	}
+}
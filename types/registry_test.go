@@ -0,0 +1,51 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/status-im/keycard-go/hexutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseApplicationEntries(t *testing.T) {
+	data := hexutils.HexToBytes("E31D4F08A0000008040001019F700107C503000000CC08A000000151000000E3114F08A0000008040001039F700183C50100")
+	entries, err := ParseApplicationEntries(data)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	assert.Equal(t, "A000000804000101", hexutils.BytesToHex(entries[0].AID))
+	assert.Equal(t, ApplicationLifeCycleSelectable, entries[0].LifeCycle)
+	assert.Equal(t, "SELECTABLE", entries[0].LifeCycle.String())
+	assert.Equal(t, []byte{0x00, 0x00, 0x00}, entries[0].Privileges)
+	assert.Equal(t, "A000000151000000", hexutils.BytesToHex(entries[0].SecurityDomainAID))
+
+	assert.Equal(t, "A000000804000103", hexutils.BytesToHex(entries[1].AID))
+	assert.True(t, entries[1].LifeCycle.Locked())
+	assert.Equal(t, "LOCKED", entries[1].LifeCycle.String())
+	assert.Nil(t, entries[1].SecurityDomainAID)
+
+	entries, err = ParseApplicationEntries([]byte{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
+
+func TestParseLoadFileEntries(t *testing.T) {
+	data := hexutils.HexToBytes("E32F4F07A00000080400019F700101CE0203018408A0000008040001018408A000000804000102CC08A000000151000000")
+	entries, err := ParseLoadFileEntries(data)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Equal(t, "A0000008040001", hexutils.BytesToHex(entries[0].AID))
+	assert.Equal(t, "LOADED", entries[0].LifeCycle.String())
+	assert.Equal(t, []byte{0x03, 0x01}, entries[0].Version)
+	assert.Len(t, entries[0].Modules, 2)
+	assert.Equal(t, "A000000804000101", hexutils.BytesToHex(entries[0].Modules[0]))
+	assert.Equal(t, "A000000804000102", hexutils.BytesToHex(entries[0].Modules[1]))
+}
+
+func TestParseApplicationEntries_Invalid(t *testing.T) {
+	// missing life cycle
+	data := hexutils.HexToBytes("E30A4F08A000000804000101")
+	_, err := ParseApplicationEntries(data)
+	assert.Equal(t, ErrInvalidRegistryEntry, err)
+}
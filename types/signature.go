@@ -18,6 +18,7 @@ type Signature struct {
 	r      []byte
 	s      []byte
 	v      byte
+	path   string
 }
 
 func ParseSignature(message, resp []byte) (*Signature, error) {
@@ -36,6 +37,18 @@ func ParseSignature(message, resp []byte) (*Signature, error) {
 	return ParseRecoverableSignature(message, sig)
 }
 
+// ParseSignatureWithPath parses a signature made with the key at the specified absolute path.
+func ParseSignatureWithPath(message, resp []byte, path string) (*Signature, error) {
+	sig, err := ParseSignature(message, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	sig.path = path
+
+	return sig, nil
+}
+
 func ParseRecoverableSignature(message, sig []byte) (*Signature, error) {
 	if len(sig) != 65 {
 		return nil, errors.New("invalid signature")
@@ -92,6 +105,11 @@ func (s *Signature) V() byte {
 	return s.v
 }
 
+// Path returns the absolute derivation path of the signing key, or an empty string if unknown.
+func (s *Signature) Path() string {
+	return s.path
+}
+
 func parseLegacySignature(message, template []byte) (*Signature, error) {
 	pubKey, err := apdu.FindTag(template, apdu.Tag{0x80})
 	if err != nil {